@@ -0,0 +1,322 @@
+package rqlParser
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// aipComparisonOps maps the AIP-160 comparators to the RQL operators
+var aipComparisonOps = map[Token]string{
+	EQUAL_SIGN:       "eq",
+	NOT_EQUAL:        "ne",
+	LOWER:            "lt",
+	LOWER_OR_EQUAL:   "le",
+	GREATER:          "gt",
+	GREATER_OR_EQUAL: "ge",
+	COLON:            "has",
+}
+
+// ParseAIP parses an AIP-160 filter (eg: state = ACTIVE AND create_time > "2024-01-01").
+// The "=" comparisons with a value containing a "*" are translated to like
+// and the presence test "field:*" to ne(field,null). As RQL has no "has"
+// operator, "field:value" is translated to eq.
+func (p *Parser) ParseAIP(filter string) (*RqlRootNode, error) {
+	ps := &parser{s: NewScanner(), maxDepth: p.maxDepth}
+	ps.s.r = bufio.NewReader(strings.NewReader(filter))
+
+	root := &RqlRootNode{}
+
+	var err error
+	if root.Node, err = ps.parseAIPFilter(); err != nil {
+		return nil, err
+	}
+
+	if err = p.validate(root); err != nil {
+		return nil, err
+	}
+
+	return root, nil
+}
+
+// parseAIPFilter parses an AIP-160 filter. The grammar is :
+//
+//	filter      = [ expression ] EOF
+//	expression  = factor { [ "AND" ] factor }   (juxtaposed factors are and-ed)
+//	factor      = term { "OR" term }
+//	term        = [ "NOT" | "-" ] simple
+//	simple      = "(" expression ")" | restriction
+//	restriction = IDENT comparator value
+//	comparator  = "=" | "!=" | "<" | "<=" | ">" | ">=" | ":"
+//	value       = IDENT | STRING
+//
+// The keywords are case sensitive. As in RQL, OR binds tighter than AND.
+func (p *parser) parseAIPFilter() (*RqlNode, error) {
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if p.tok.t == EOF {
+		return nil, nil
+	}
+
+	n, err := p.parseAIPExpression()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.t != EOF {
+		return nil, p.unexpected()
+	}
+
+	return n, nil
+}
+
+func (p *parser) isAIPKeyword(keyword string) bool {
+	return p.tok.t == IDENT && p.tok.s == keyword
+}
+
+func (p *parser) parseAIPExpression() (*RqlNode, error) {
+	var args []interface{}
+
+	for {
+		factor, err := p.parseAIPFactor()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, factor)
+
+		if p.isAIPKeyword("AND") {
+			if err = p.next(); err != nil {
+				return nil, err
+			}
+		} else if p.tok.t != IDENT && p.tok.t != OPENING_PARENTHESIS {
+			break
+		}
+	}
+
+	if len(args) == 1 {
+		return args[0].(*RqlNode), nil
+	}
+
+	return &RqlNode{Op: "AND", Args: args}, nil
+}
+
+func (p *parser) parseAIPFactor() (*RqlNode, error) {
+	return p.parseRSQLList("OR", func() bool {
+		return p.isAIPKeyword("OR")
+	}, p.parseAIPTerm)
+}
+
+func (p *parser) parseAIPTerm() (*RqlNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	negated := false
+	if p.isAIPKeyword("NOT") || p.isAIPKeyword("-") {
+		negated = true
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	} else if p.tok.t == IDENT && strings.HasPrefix(p.tok.s, "-") {
+		// The minus is read with the field name (eg: -state = ACTIVE)
+		negated = true
+		p.tok.s = p.tok.s[1:]
+	}
+
+	n, err := p.parseAIPSimple()
+	if err != nil {
+		return nil, err
+	}
+	if negated {
+		n = &RqlNode{Op: "not", Args: []interface{}{n}}
+	}
+
+	return n, nil
+}
+
+func (p *parser) parseAIPSimple() (*RqlNode, error) {
+	if p.tok.t == OPENING_PARENTHESIS {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		n, err := p.parseAIPExpression()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.t != CLOSING_PARENTHESIS {
+			return nil, fmt.Errorf("Missing closing parenthesis")
+		}
+		return n, p.next()
+	}
+
+	if p.tok.t != IDENT || p.isAIPKeyword("AND") || p.isAIPKeyword("OR") {
+		return nil, p.unexpected()
+	}
+	field := p.tok.s
+
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	op, ok := aipComparisonOps[p.tok.t]
+	if !ok {
+		return nil, fmt.Errorf("Missing comparison operator after %s", field)
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	value, err := p.parseRSQLValue()
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "has":
+		if value == "*" {
+			return &RqlNode{Op: "ne", Args: []interface{}{field, "null"}}, nil
+		}
+		op = "eq"
+	case "eq":
+		if aipPattern(value) != "" {
+			op = "like"
+		}
+	}
+
+	return &RqlNode{Op: op, Args: []interface{}{field, value}}, nil
+}
+
+// aipPattern returns the value if it contains a wildcard
+func aipPattern(value interface{}) string {
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case StringLiteral:
+		s = string(v)
+	}
+	if strings.Contains(s, "*") {
+		return s
+	}
+	return ""
+}
+
+// FormatAIP returns the AIP-160 filter of a node
+func FormatAIP(n *RqlNode) (string, error) {
+	if n == nil {
+		return "", nil
+	}
+
+	switch op := strings.ToLower(n.Op); op {
+	case "and", "or":
+		exprs := make([]string, len(n.Args))
+		for i, a := range n.Args {
+			c, ok := a.(*RqlNode)
+			if !ok {
+				return "", &ArgumentError{Op: n.Op, Arg: a, Reason: "must be an operator"}
+			}
+			s, err := formatAIPOperand(c)
+			if err != nil {
+				return "", err
+			}
+			exprs[i] = s
+		}
+		return strings.Join(exprs, " "+strings.ToUpper(op)+" "), nil
+	case "not":
+		if len(n.Args) != 1 {
+			return "", &ArgumentError{Op: n.Op, Reason: "requires 1 argument"}
+		}
+		c, ok := n.Args[0].(*RqlNode)
+		if !ok {
+			return "", &ArgumentError{Op: n.Op, Arg: n.Args[0], Reason: "must be an operator"}
+		}
+		s, err := formatAIPOperand(c)
+		if err != nil {
+			return "", err
+		}
+		return "NOT " + s, nil
+	case "eq", "ne", "gt", "ge", "lt", "le", "like":
+		field, value, err := aipFieldValue(n)
+		if err != nil {
+			return "", err
+		}
+		if op == "ne" && value == "null" {
+			return field + ":*", nil
+		}
+		if op == "like" {
+			return field + " = " + quoteAIPString(fmt.Sprint(value)), nil
+		}
+		if aipPattern(value) != "" {
+			return "", &ArgumentError{Op: n.Op, Arg: value, Reason: "has no AIP-160 equivalent"}
+		}
+		comparator := map[string]string{"eq": "=", "ne": "!=", "gt": ">", "ge": ">=", "lt": "<", "le": "<="}[op]
+		return field + " " + comparator + " " + formatAIPValue(value), nil
+	case "in", "out":
+		if len(n.Args) < 2 {
+			return "", &ArgumentError{Op: n.Op, Reason: "requires at least 2 arguments"}
+		}
+		or := &RqlNode{Op: "OR"}
+		for _, a := range n.Args[1:] {
+			or.Args = append(or.Args, &RqlNode{Op: "eq", Args: []interface{}{n.Args[0], a}})
+		}
+		s, err := formatAIPOperand(or)
+		if err != nil {
+			return "", err
+		}
+		if op == "out" {
+			s = "NOT " + s
+		}
+		return s, nil
+	}
+
+	return "", fmt.Errorf("No AIP-160 equivalent for op : '%s'", n.Op)
+}
+
+// formatAIPOperand returns the filter of an operand, the and/or expressions are parenthesized
+func formatAIPOperand(n *RqlNode) (string, error) {
+	s, err := FormatAIP(n)
+	if err != nil {
+		return "", err
+	}
+	if op := strings.ToLower(n.Op); (op == "and" || op == "or") && len(n.Args) > 1 {
+		s = "(" + s + ")"
+	}
+	return s, nil
+}
+
+// aipFieldValue returns the field and the value of a comparison
+func aipFieldValue(n *RqlNode) (string, interface{}, error) {
+	if len(n.Args) != 2 {
+		return "", nil, &ArgumentError{Op: n.Op, Reason: "requires 2 arguments"}
+	}
+	field, ok := n.Args[0].(string)
+	if !ok || !IsValidField(field) || strings.HasPrefix(field, "-") {
+		return "", nil, &ArgumentError{Op: n.Op, Arg: n.Args[0], Reason: "must be a field name"}
+	}
+	switch n.Args[1].(type) {
+	case string, StringLiteral:
+	default:
+		return "", nil, &ArgumentError{Op: n.Op, Arg: n.Args[1], Reason: "must be a value"}
+	}
+	return field, n.Args[1], nil
+}
+
+// formatAIPValue returns the unquoted values as text when they are read back unchanged
+func formatAIPValue(value interface{}) string {
+	s, ok := value.(string)
+	if !ok || s == "" || s == "AND" || s == "OR" || s == "NOT" {
+		return quoteAIPString(fmt.Sprint(value))
+	}
+	for _, ch := range s {
+		if !isIdent(ch) || ch == '%' {
+			return quoteAIPString(s)
+		}
+	}
+	return s
+}
+
+func quoteAIPString(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + r.Replace(s) + `"`
+}
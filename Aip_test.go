@@ -0,0 +1,152 @@
+package rqlParser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type AIPTest struct {
+	Name           string // Name of the test
+	AIP            string // Input AIP-160 filter
+	RQL            string // Equivalent RQL query producing the same tree
+	SQL            string // Expected Output SQL
+	Formatted      string // Expected output of FormatAIP (the round trip is always checked)
+	WantParseError bool   // Test should raise an error when parsing the filter
+}
+
+func (test *AIPTest) Run(t *testing.T) {
+	root, err := NewParser().ParseAIP(test.AIP)
+	if test.WantParseError != (err != nil) {
+		t.Fatalf("(%s) Expecting error :%v\nGot error : %v", test.Name, test.WantParseError, err)
+	}
+	if err != nil {
+		return
+	}
+
+	if test.RQL != "" {
+		rqlRoot, err := NewParser().Parse(strings.NewReader(test.RQL))
+		if err != nil {
+			t.Fatalf("(%s) Unexpected RQL parse error : %v", test.Name, err)
+		}
+		if !reflect.DeepEqual(root.Node, rqlRoot.Node) {
+			t.Fatalf("(%s) AIP-160 tree doesn’t match the RQL one %v vs %v", test.Name, root.Node, rqlRoot.Node)
+		}
+	}
+
+	s, err := NewSqlTranslator(root).Sql()
+	if err != nil {
+		t.Fatalf("(%s) Unexpected translator error : %v", test.Name, err)
+	}
+	if s != test.SQL {
+		t.Fatalf("(%s) Translated SQL doesn’t match the expected one %s vs %s", test.Name, s, test.SQL)
+	}
+
+	filter, err := FormatAIP(root.Node)
+	if err != nil {
+		t.Fatalf("(%s) Unexpected format error : %v", test.Name, err)
+	}
+	if test.Formatted != "" && filter != test.Formatted {
+		t.Fatalf("(%s) Formatted filter doesn’t match the expected one %s vs %s", test.Name, filter, test.Formatted)
+	}
+	roundTrip, err := NewParser().ParseAIP(filter)
+	if err != nil {
+		t.Fatalf("(%s) Unexpected error parsing the formatted filter %s : %v", test.Name, filter, err)
+	}
+	if !reflect.DeepEqual(root.Node, roundTrip.Node) {
+		t.Fatalf("(%s) Round trip doesn’t match %v vs %v", test.Name, roundTrip.Node, root.Node)
+	}
+}
+
+var aipTests = []AIPTest{
+	{
+		Name:      `Comparisons and string literals`,
+		AIP:       `state = ACTIVE AND create_time > "2024-01-01"`,
+		RQL:       `eq(state,ACTIVE)&gt(create_time,"2024-01-01")`,
+		SQL:       `WHERE ((state = 'ACTIVE') AND (create_time > '2024-01-01'))`,
+		Formatted: `state = ACTIVE AND create_time > "2024-01-01"`,
+	},
+	{
+		Name:      `Presence`,
+		AIP:       `labels.env:*`,
+		RQL:       `ne(labels.env,null)`,
+		SQL:       `WHERE (labels.env IS NOT NULL)`,
+		Formatted: `labels.env:*`,
+	},
+	{
+		Name:      `Implicit and, precedence of or over and`,
+		AIP:       `a = 1 b < 2 OR c >= 3 AND d != "x"`,
+		RQL:       `eq(a,1)&(lt(b,2)|ge(c,3))&ne(d,"x")`,
+		SQL:       `WHERE ((a = 1) AND ((b < 2) OR (c >= 3)) AND (d != 'x'))`,
+		Formatted: `a = 1 AND (b < 2 OR c >= 3) AND d != "x"`,
+	},
+	{
+		Name:      `Negation and grouping`,
+		AIP:       `NOT a = 1 AND -b <= 2 AND -(c = 3 AND d > 4) AND (e = 5 OR f = 6)`,
+		RQL:       `not(eq(a,1))&not(le(b,2))&not(eq(c,3)&gt(d,4))&(eq(e,5)|eq(f,6))`,
+		SQL:       `WHERE (NOT((a = 1)) AND NOT((b <= 2)) AND NOT(((c = 3) AND (d > 4))) AND ((e = 5) OR (f = 6)))`,
+		Formatted: `NOT a = 1 AND NOT b <= 2 AND NOT (c = 3 AND d > 4) AND (e = 5 OR f = 6)`,
+	},
+	{
+		Name:      `Wildcards and has`,
+		AIP:       `name = "*.foo" AND tags:urgent AND note = "say \"hi\""`,
+		RQL:       `like(name,"*.foo")&eq(tags,urgent)&eq(note,"say \"hi\"")`,
+		SQL:       `WHERE ((name LIKE '%.foo') AND (tags = 'urgent') AND (note = 'say "hi"'))`,
+		Formatted: `name = "*.foo" AND tags = urgent AND note = "say \"hi\""`,
+	},
+	{
+		Name: `Empty filter`,
+		AIP:  ``,
+		SQL:  ``,
+	},
+	{
+		Name:           `Lowercase keywords are values`,
+		AIP:            `a = 1 and b = 2`,
+		WantParseError: true,
+	},
+	{
+		Name:           `Global restriction`,
+		AIP:            `"hello"`,
+		WantParseError: true,
+	},
+	{
+		Name:           `Missing comparator`,
+		AIP:            `a AND b = 1`,
+		WantParseError: true,
+	},
+	{
+		Name:           `Missing closing parenthesis`,
+		AIP:            `(a = 1 OR b = 2`,
+		WantParseError: true,
+	},
+}
+
+func TestAIP(t *testing.T) {
+	for _, test := range aipTests {
+		test.Run(t)
+	}
+}
+
+func TestFormatAIP(t *testing.T) {
+	root, err := NewParser().Parse(strings.NewReader(`in(a,1,x)&out(b,"y")&eq(c,"1 2")`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	filter, err := FormatAIP(root.Node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `(a = 1 OR a = x) AND NOT b = "y" AND c = "1 2"`; filter != expected {
+		t.Fatalf("Formatted filter doesn’t match the expected one %s vs %s", filter, expected)
+	}
+
+	for _, rql := range []string{`eq(a,b*)`, `match(a,b)`} {
+		root, err := NewParser().Parse(strings.NewReader(rql))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = FormatAIP(root.Node); err == nil {
+			t.Fatalf("Expecting an error formatting %s", rql)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package rqlParser
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BracketAdapter converts bracket style query parameters (eg:
+// filter[status]=open&price[gte]=10&sort=-created&page[size]=20) into a RqlRootNode
+type BracketAdapter struct {
+	// Parser provides the reserved parameters, which are ignored, and the
+	// OpSpecs validating the resulting tree
+	Parser *Parser
+	// Ops maps the operator names used in the brackets to the RQL operators
+	Ops map[string]string
+	// FilterParam is the name of the filters parameter (eg: filter[status]=open)
+	FilterParam string
+	// SortParam is the name of the sort parameter (eg: sort=-created,name)
+	SortParam string
+	// PageParam is the name of the pagination parameter (eg: page[size]=20&page[number]=2)
+	PageParam string
+	// Fields are the fields which can be filtered by a bare parameter (eg:
+	// status=open or price[gte]=10). The other parameters (eg: include=author
+	// or fields[articles]=title) are ignored.
+	Fields []string
+}
+
+func NewBracketAdapter() *BracketAdapter {
+	return &BracketAdapter{
+		Parser: NewParser(),
+		Ops: map[string]string{
+			"eq":   "eq",
+			"ne":   "ne",
+			"gt":   "gt",
+			"gte":  "ge",
+			"lt":   "lt",
+			"lte":  "le",
+			"like": "like",
+			"in":   "in",
+			"nin":  "out",
+		},
+		FilterParam: "filter",
+		SortParam:   "sort",
+		PageParam:   "page",
+	}
+}
+
+// Parse converts the query parameters. A parameter without operator is an
+// equality (eg: filter[status]=open, or status=open when status is one of the
+// Fields) and the values of the list operators (in, nin) are separated by commas. As url.Values are not
+// ordered, the filters are combined by key alphabetical order.
+func (a *BracketAdapter) Parse(values url.Values) (*RqlRootNode, error) {
+	var (
+		keys    []string
+		filters []interface{}
+		page    = map[string]string{}
+	)
+
+	root := &RqlRootNode{}
+
+	for k := range values {
+		if a.Parser == nil || !a.Parser.reservedParams[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		name, segments, ok := parseBracketKey(k)
+		if !ok {
+			return nil, fmt.Errorf("Invalid query parameter %s", k)
+		}
+
+		switch {
+		case name == a.SortParam && len(segments) == 0:
+			sortNode := &RqlNode{Op: "sort"}
+			for _, v := range values[k] {
+				for _, key := range strings.Split(v, ",") {
+					sortNode.Args = append(sortNode.Args, key)
+				}
+			}
+			if _, err := parseSort(sortNode, root); err != nil {
+				return nil, err
+			}
+		case name == a.PageParam && len(segments) == 1:
+			page[segments[0]] = values.Get(k)
+		case name == a.FilterParam && len(segments) > 0:
+			if len(segments) > 2 {
+				return nil, fmt.Errorf("Invalid query parameter %s", k)
+			}
+			for _, v := range values[k] {
+				n, err := a.filter(k, segments[0], segments[1:], v)
+				if err != nil {
+					return nil, err
+				}
+				filters = append(filters, n)
+			}
+		default:
+			if !a.isField(name) {
+				continue
+			}
+			if len(segments) > 1 {
+				return nil, fmt.Errorf("Invalid query parameter %s", k)
+			}
+			for _, v := range values[k] {
+				n, err := a.filter(k, name, segments, v)
+				if err != nil {
+					return nil, err
+				}
+				filters = append(filters, n)
+			}
+		}
+	}
+
+	if len(filters) == 1 {
+		root.Node = filters[0].(*RqlNode)
+	} else if len(filters) > 1 {
+		root.Node = &RqlNode{Op: "AND", Args: filters}
+	}
+
+	if err := a.parsePage(page, root); err != nil {
+		return nil, err
+	}
+
+	if err := a.Parser.validate(root); err != nil {
+		return nil, err
+	}
+
+	return root, nil
+}
+
+// isField reports whether the field can be filtered by a bare parameter
+func (a *BracketAdapter) isField(field string) bool {
+	for _, f := range a.Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// filter returns the node of a filter parameter, op is empty or holds the operator name
+func (a *BracketAdapter) filter(param, field string, op []string, value string) (*RqlNode, error) {
+	if !IsValidField(field) {
+		return nil, fmt.Errorf("Invalid field name : %s", field)
+	}
+
+	rqlOp := "eq"
+	if len(op) > 0 {
+		var ok bool
+		if rqlOp, ok = a.Ops[op[0]]; !ok {
+			return nil, fmt.Errorf("Unknown operator %s in query parameter %s", op[0], param)
+		}
+	}
+
+	n := &RqlNode{Op: rqlOp, Args: []interface{}{field}}
+	if spec, ok := a.specs().Get(rqlOp); ok && len(spec.Kinds) > 0 && spec.Kinds[len(spec.Kinds)-1]&ListArg != 0 {
+		for _, v := range strings.Split(value, ",") {
+			n.Args = append(n.Args, v)
+		}
+	} else {
+		n.Args = append(n.Args, value)
+	}
+
+	return n, nil
+}
+
+func (a *BracketAdapter) specs() *OpSpecs {
+	if a.Parser != nil && a.Parser.opSpecs != nil {
+		return a.Parser.opSpecs
+	}
+	return NewOpSpecs()
+}
+
+// parsePage sets the limit and the offset from page[size] (or page[limit]) and
+// page[number] (from 1) or page[offset]
+func (a *BracketAdapter) parsePage(page map[string]string, root *RqlRootNode) error {
+	for k := range page {
+		switch k {
+		case "size", "limit", "number", "offset":
+		default:
+			return fmt.Errorf("Invalid query parameter %s[%s]", a.PageParam, k)
+		}
+	}
+
+	size, ok := page["size"]
+	if !ok {
+		size = page["limit"]
+	}
+	offset := page["offset"]
+
+	if number, ok := page["number"]; ok {
+		param := a.PageParam + "[number]"
+		n, err := strconv.Atoi(number)
+		if err != nil || n < 1 {
+			return &ArgumentError{Op: param, Arg: number, Reason: "must be a positive integer"}
+		}
+		if size == "" {
+			return &ArgumentError{Op: param, Reason: "requires a page size"}
+		}
+		s, err := parseNonNegativeInt(a.PageParam+"[size]", size)
+		if err != nil {
+			return err
+		}
+		offset = strconv.Itoa((n - 1) * s)
+	}
+
+	if size == "" {
+		if offset == "" {
+			return nil
+		}
+		o, err := parseNonNegativeInt(a.PageParam+"[offset]", offset)
+		if err != nil {
+			return err
+		}
+		root.offset, root.offsetInt = strconv.Itoa(o), o
+		return nil
+	}
+
+	limit := &RqlNode{Op: "limit", Args: []interface{}{size}}
+	if offset != "" {
+		limit.Args = append(limit.Args, offset)
+	}
+	_, err := parseLimit(limit, root)
+	return err
+}
+
+// parseBracketKey splits a parameter name (eg: filter[price][gte]) into its
+// name and its bracket segments
+func parseBracketKey(key string) (name string, segments []string, ok bool) {
+	i := strings.IndexByte(key, '[')
+	if i < 0 {
+		return key, nil, !strings.ContainsRune(key, ']')
+	}
+	name, rest := key[:i], key[i:]
+
+	for rest != "" {
+		end := strings.IndexByte(rest, ']')
+		if rest[0] != '[' || end < 0 {
+			return "", nil, false
+		}
+		segment := rest[1:end]
+		if segment == "" || strings.ContainsRune(segment, '[') {
+			return "", nil, false
+		}
+		segments = append(segments, segment)
+		rest = rest[end+1:]
+	}
+
+	return name, segments, name != ""
+}
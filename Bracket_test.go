@@ -0,0 +1,142 @@
+package rqlParser
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type BracketTest struct {
+	Name           string // Name of the test
+	Query          string // Input query string
+	RQL            string // Equivalent RQL query producing the same root node
+	SQL            string // Expected Output SQL
+	WantParseError bool   // Test should raise an error when converting the query
+}
+
+func (test *BracketTest) Run(t *testing.T, a *BracketAdapter) {
+	values, err := url.ParseQuery(test.Query)
+	if err != nil {
+		t.Fatalf("(%s) Invalid query string : %v", test.Name, err)
+	}
+
+	root, err := a.Parse(values)
+	if test.WantParseError != (err != nil) {
+		t.Fatalf("(%s) Expecting error :%v\nGot error : %v", test.Name, test.WantParseError, err)
+	}
+	if err != nil {
+		return
+	}
+
+	if test.RQL != "" {
+		rqlRoot, err := NewParser().Parse(strings.NewReader(test.RQL))
+		if err != nil {
+			t.Fatalf("(%s) Unexpected RQL parse error : %v", test.Name, err)
+		}
+		if !reflect.DeepEqual(root, rqlRoot) {
+			t.Fatalf("(%s) Root doesn’t match the RQL one %+v vs %+v", test.Name, root, rqlRoot)
+		}
+	}
+
+	s, err := NewSqlTranslator(root).Sql()
+	if err != nil {
+		t.Fatalf("(%s) Unexpected translator error : %v", test.Name, err)
+	}
+	if s != test.SQL {
+		t.Fatalf("(%s) Translated SQL doesn’t match the expected one %s vs %s", test.Name, s, test.SQL)
+	}
+}
+
+var bracketTests = []BracketTest{
+	{
+		Name:  `Filters, sort and page size`,
+		Query: `filter[status]=open&price[gte]=10&sort=-created&page[size]=20`,
+		RQL:   `eq(status,open)&ge(price,10)&sort(-created)&limit(20)`,
+		SQL:   `WHERE ((status = 'open') AND (price >= 10)) ORDER BY created DESC LIMIT 20`,
+	},
+	{
+		Name:  `Operators in the filter parameter and lists`,
+		Query: `filter[price][lt]=100&filter[tag][in]=a,b&filter[type][nin]=x&name=bob&sort=name,-id`,
+		RQL:   `lt(price,100)&in(tag,a,b)&out(type,x)&eq(name,bob)&sort(+name,-id)`,
+		SQL:   `WHERE ((price < 100) AND (tag IN ('a', 'b')) AND (type NOT IN ('x')) AND (name = 'bob')) ORDER BY name, id DESC`,
+	},
+	{
+		Name:  `Page number`,
+		Query: `page[number]=3&page[size]=20`,
+		RQL:   `limit(20,40)`,
+		SQL:   ` LIMIT 20 OFFSET 40`,
+	},
+	{
+		Name:  `Page limit and offset`,
+		Query: `page[limit]=10&page[offset]=5&a[ne]=1`,
+		RQL:   `ne(a,1)&limit(10,5)`,
+		SQL:   `WHERE (a != 1) LIMIT 10 OFFSET 5`,
+	},
+	{
+		Name:  `Reserved parameter`,
+		Query: `api_key=secret&a=1`,
+		RQL:   `eq(a,1)`,
+		SQL:   `WHERE (a = 1)`,
+	},
+	{
+		Name:  `Parameters which are not filters`,
+		Query: `include=author&fields[articles]=title&page=2&status=open&filter[status]=closed`,
+		RQL:   `eq(status,closed)`,
+		SQL:   `WHERE (status = 'closed')`,
+	},
+	{
+		Name:           `Unknown operator`,
+		Query:          `price[between]=1`,
+		WantParseError: true,
+	},
+	{
+		Name:           `Invalid field`,
+		Query:          `filter[a b]=1`,
+		WantParseError: true,
+	},
+	{
+		Name:           `Malformed brackets`,
+		Query:          `price[gte=10`,
+		WantParseError: true,
+	},
+	{
+		Name:           `Page number without size`,
+		Query:          `page[number]=2`,
+		WantParseError: true,
+	},
+	{
+		Name:           `Invalid page size`,
+		Query:          `page[size]=-1`,
+		WantParseError: true,
+	},
+	{
+		Name:           `Invalid sort`,
+		Query:          `sort=-`,
+		WantParseError: true,
+	},
+}
+
+func TestBracketAdapter(t *testing.T) {
+	a := NewBracketAdapter()
+	a.Parser.SetReservedParams("api_key")
+	a.Fields = []string{"a", "api_key", "name", "price"}
+
+	for _, test := range bracketTests {
+		test.Run(t, a)
+	}
+}
+
+func TestBracketAdapterOps(t *testing.T) {
+	a := NewBracketAdapter()
+	a.Ops["contains"] = "like"
+	a.FilterParam = "where"
+
+	test := BracketTest{
+		Name:  `Custom operator and filter parameter`,
+		Query: `where[name][contains]=*bob*`,
+		RQL:   `like(name,*bob*)`,
+		SQL:   `WHERE (name LIKE '%bob%')`,
+	}
+	test.Run(t, a)
+}
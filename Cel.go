@@ -0,0 +1,434 @@
+package rqlParser
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// celComparisonOps maps the CEL comparators to the RQL operators
+var celComparisonOps = map[Token]string{
+	EQUAL:            "eq",
+	NOT_EQUAL:        "ne",
+	LOWER:            "lt",
+	LOWER_OR_EQUAL:   "le",
+	GREATER:          "gt",
+	GREATER_OR_EQUAL: "ge",
+}
+
+// celMethods maps the CEL string methods to the like patterns
+var celMethods = map[string]string{
+	"startsWith": "%s*",
+	"endsWith":   "*%s",
+	"contains":   "*%s*",
+}
+
+// celField matches the CEL field selections (eg: resource.owner)
+var celField = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// ParseCEL parses the comparable subset of CEL (eg: price > 10 && status == "open").
+// The supported expressions are the comparisons of a field to a literal,
+// "field in [...]", the startsWith, endsWith and contains methods (translated
+// to like), &&, || and !. The CEL strings are converted to StringLiteral.
+func (p *Parser) ParseCEL(expr string) (*RqlRootNode, error) {
+	ps := &parser{s: NewScanner(), maxDepth: p.maxDepth}
+	ps.s.r = bufio.NewReader(strings.NewReader(expr))
+
+	root := &RqlRootNode{}
+
+	var err error
+	if root.Node, err = ps.parseCELExpr(); err != nil {
+		return nil, err
+	}
+
+	if err = p.validate(root); err != nil {
+		return nil, err
+	}
+
+	return root, nil
+}
+
+// parseCELExpr parses a CEL expression. The grammar is :
+//
+//	expr     = [ or ] EOF
+//	or       = and { "||" and }
+//	and      = unary { "&&" unary }
+//	unary    = "!" unary | primary
+//	primary  = "(" or ")" | relation
+//	relation = FIELD comparator literal | FIELD "in" "[" literal { "," literal } "]"
+//	         | FIELD "." method "(" STRING ")"
+//	literal  = STRING | NUMBER | "true" | "false" | "null"
+func (p *parser) parseCELExpr() (*RqlNode, error) {
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if p.tok.t == EOF {
+		return nil, nil
+	}
+
+	n, err := p.parseCELOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.t != EOF {
+		return nil, p.unexpected()
+	}
+
+	return n, nil
+}
+
+func (p *parser) parseCELOr() (*RqlNode, error) {
+	return p.parseCELList("OR", PIPE, p.parseCELAnd)
+}
+
+func (p *parser) parseCELAnd() (*RqlNode, error) {
+	return p.parseCELList("AND", AMPERSAND, p.parseCELUnary)
+}
+
+// parseCELList parses the items separated by a doubled token (&& or ||)
+func (p *parser) parseCELList(op string, separator Token, parseItem func() (*RqlNode, error)) (*RqlNode, error) {
+	return p.parseRSQLList(op, func() bool {
+		if p.tok.t != separator {
+			return false
+		}
+		// The first token is consumed here, the second one by parseRSQLList
+		next, err := p.peek()
+		if err != nil || next.t != separator {
+			return false
+		}
+		return p.next() == nil
+	}, parseItem)
+}
+
+func (p *parser) parseCELUnary() (*RqlNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	if p.tok.t != EXCLAMATION_MARK {
+		return p.parseCELPrimary()
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	n, err := p.parseCELUnary()
+	if err != nil {
+		return nil, err
+	}
+	if n.Op == "in" {
+		n.Op = "out"
+		return n, nil
+	}
+	return &RqlNode{Op: "not", Args: []interface{}{n}}, nil
+}
+
+func (p *parser) parseCELPrimary() (*RqlNode, error) {
+	if p.tok.t == OPENING_PARENTHESIS {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		n, err := p.parseCELOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.t != CLOSING_PARENTHESIS {
+			return nil, fmt.Errorf("Missing closing parenthesis")
+		}
+		return n, p.next()
+	}
+
+	if p.tok.t != IDENT {
+		return nil, p.unexpected()
+	}
+	field := p.tok.s
+
+	next, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+	if next.t == OPENING_PARENTHESIS {
+		return p.parseCELMethod()
+	}
+
+	if !celField.MatchString(field) {
+		return nil, fmt.Errorf("Invalid field name : %s", field)
+	}
+	if err = p.next(); err != nil {
+		return nil, err
+	}
+
+	if p.tok.t == IDENT && p.tok.s == "in" {
+		return p.parseCELIn(field)
+	}
+
+	op, ok := celComparisonOps[p.tok.t]
+	if !ok {
+		return nil, fmt.Errorf("Missing comparison operator after %s", field)
+	}
+	if err = p.next(); err != nil {
+		return nil, err
+	}
+
+	value, err := p.parseCELLiteral()
+	if err != nil {
+		return nil, err
+	}
+
+	return &RqlNode{Op: op, Args: []interface{}{field, value}}, nil
+}
+
+// parseCELMethod parses a string method call, the current token holds the
+// field and the method (eg: name.startsWith)
+func (p *parser) parseCELMethod() (*RqlNode, error) {
+	call := p.tok.s
+
+	i := strings.LastIndexByte(call, '.')
+	if i < 0 {
+		return nil, fmt.Errorf("Unsupported CEL function : %s", call)
+	}
+	field, method := call[:i], call[i+1:]
+
+	format, ok := celMethods[method]
+	if !ok {
+		return nil, fmt.Errorf("Unsupported CEL function : %s", method)
+	}
+	if !celField.MatchString(field) {
+		return nil, fmt.Errorf("Invalid field name : %s", field)
+	}
+
+	// The method and the opening parenthesis
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	if p.tok.t != STRING {
+		return nil, &ArgumentError{Op: method, Arg: p.tok.s, Reason: "must be a string"}
+	}
+	if strings.Contains(p.tok.s, "*") {
+		return nil, &ArgumentError{Op: method, Arg: p.tok.s, Reason: "must not contain a '*'"}
+	}
+	pattern := StringLiteral(fmt.Sprintf(format, p.tok.s))
+
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if p.tok.t != CLOSING_PARENTHESIS {
+		return nil, fmt.Errorf("Missing closing parenthesis")
+	}
+
+	return &RqlNode{Op: "like", Args: []interface{}{field, pattern}}, p.next()
+}
+
+func (p *parser) parseCELIn(field string) (*RqlNode, error) {
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if p.tok.t != OPENING_BRACKET {
+		return nil, p.unexpected()
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	n := &RqlNode{Op: "in", Args: []interface{}{field}}
+	for {
+		value, err := p.parseCELLiteral()
+		if err != nil {
+			return nil, err
+		}
+		n.Args = append(n.Args, value)
+
+		if p.tok.t != COMMA {
+			break
+		}
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.tok.t != CLOSING_BRACKET {
+		return nil, fmt.Errorf("Missing closing bracket")
+	}
+
+	return n, p.next()
+}
+
+// parseCELLiteral parses a string, a number, a boolean or null
+func (p *parser) parseCELLiteral() (interface{}, error) {
+	if p.tok.t == IDENT && !isCELLiteral(p.tok.s) {
+		return nil, fmt.Errorf("Unsupported CEL value : %s", p.tok.s)
+	}
+	return p.parseRSQLValue()
+}
+
+func isCELLiteral(s string) bool {
+	return isNumber(s) || s == "true" || s == "false" || s == "null"
+}
+
+// FormatCEL returns the CEL expression of a node. The like patterns are
+// translated to startsWith, endsWith or contains when possible, and to a
+// regular expression (matches) otherwise.
+func FormatCEL(n *RqlNode) (string, error) {
+	if n == nil {
+		return "", nil
+	}
+
+	switch op := strings.ToLower(n.Op); op {
+	case "and", "or":
+		if len(n.Args) == 0 {
+			return "", &ArgumentError{Op: n.Op, Reason: "requires at least 1 argument"}
+		}
+		exprs := make([]string, len(n.Args))
+		for i, a := range n.Args {
+			c, ok := a.(*RqlNode)
+			if !ok {
+				return "", &ArgumentError{Op: n.Op, Arg: a, Reason: "must be an operator"}
+			}
+			s, err := formatCELOperand(c)
+			if err != nil {
+				return "", err
+			}
+			exprs[i] = s
+		}
+		separator := " && "
+		if op == "or" {
+			separator = " || "
+		}
+		return strings.Join(exprs, separator), nil
+	case "not":
+		if len(n.Args) != 1 {
+			return "", &ArgumentError{Op: n.Op, Reason: "requires 1 argument"}
+		}
+		c, ok := n.Args[0].(*RqlNode)
+		if !ok {
+			return "", &ArgumentError{Op: n.Op, Arg: n.Args[0], Reason: "must be an operator"}
+		}
+		s, err := FormatCEL(c)
+		if err != nil {
+			return "", err
+		}
+		return "!(" + s + ")", nil
+	case "eq", "ne", "gt", "ge", "lt", "le":
+		field, value, err := celFieldValue(n)
+		if err != nil {
+			return "", err
+		}
+		comparator := map[string]string{"eq": "==", "ne": "!=", "gt": ">", "ge": ">=", "lt": "<", "le": "<="}[op]
+		return field + " " + comparator + " " + formatCELValue(value), nil
+	case "like":
+		field, value, err := celFieldValue(n)
+		if err != nil {
+			return "", err
+		}
+		return formatCELLike(field, fmt.Sprint(value)), nil
+	case "in", "out":
+		if len(n.Args) < 2 {
+			return "", &ArgumentError{Op: n.Op, Reason: "requires at least 2 arguments"}
+		}
+		field, ok := n.Args[0].(string)
+		if !ok || !celField.MatchString(field) {
+			return "", &ArgumentError{Op: n.Op, Arg: n.Args[0], Reason: "must be a field name"}
+		}
+		values := make([]string, len(n.Args)-1)
+		for i, a := range n.Args[1:] {
+			switch a.(type) {
+			case string, StringLiteral:
+				values[i] = formatCELValue(a)
+			default:
+				return "", &ArgumentError{Op: n.Op, Arg: a, Reason: "must be a value"}
+			}
+		}
+		s := field + " in [" + strings.Join(values, ", ") + "]"
+		if op == "out" {
+			s = "!(" + s + ")"
+		}
+		return s, nil
+	}
+
+	return "", fmt.Errorf("No CEL equivalent for op : '%s'", n.Op)
+}
+
+// formatCELOperand returns the expression of an operand, the and/or expressions are parenthesized
+func formatCELOperand(n *RqlNode) (string, error) {
+	s, err := FormatCEL(n)
+	if err != nil {
+		return "", err
+	}
+	if op := strings.ToLower(n.Op); (op == "and" || op == "or") && len(n.Args) > 1 {
+		s = "(" + s + ")"
+	}
+	return s, nil
+}
+
+// formatCELLike returns the string method matching a like pattern
+func formatCELLike(field, pattern string) string {
+	inner := strings.TrimSuffix(strings.TrimPrefix(pattern, "*"), "*")
+
+	if !strings.Contains(inner, "*") {
+		switch {
+		case len(pattern) > len(inner)+1:
+			return field + ".contains(" + quoteCELString(inner) + ")"
+		case strings.HasSuffix(pattern, "*"):
+			return field + ".startsWith(" + quoteCELString(inner) + ")"
+		case strings.HasPrefix(pattern, "*"):
+			return field + ".endsWith(" + quoteCELString(inner) + ")"
+		default:
+			return field + " == " + quoteCELString(inner)
+		}
+	}
+
+	parts := strings.Split(pattern, "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	return field + ".matches(" + quoteCELString("^"+strings.Join(parts, ".*")+"$") + ")"
+}
+
+// celFieldValue returns the field and the value of a comparison
+func celFieldValue(n *RqlNode) (string, interface{}, error) {
+	if len(n.Args) != 2 {
+		return "", nil, &ArgumentError{Op: n.Op, Reason: "requires 2 arguments"}
+	}
+	field, ok := n.Args[0].(string)
+	if !ok || !celField.MatchString(field) {
+		return "", nil, &ArgumentError{Op: n.Op, Arg: n.Args[0], Reason: "must be a field name"}
+	}
+	switch n.Args[1].(type) {
+	case string, StringLiteral:
+	default:
+		return "", nil, &ArgumentError{Op: n.Op, Arg: n.Args[1], Reason: "must be a value"}
+	}
+	return field, n.Args[1], nil
+}
+
+// formatCELValue returns the unquoted numbers, booleans and null as is, the other values are strings
+func formatCELValue(value interface{}) string {
+	if s, ok := value.(string); ok && isCELLiteral(s) {
+		if isNumber(s) {
+			return formatCELNumber(s)
+		}
+		return s
+	}
+	return quoteCELString(fmt.Sprint(value))
+}
+
+// formatCELNumber returns a valid CEL number literal : the leading + is
+// dropped and a fractional part is added after a trailing . (eg: 1. is 1.0)
+func formatCELNumber(s string) string {
+	s = strings.TrimPrefix(s, "+")
+	if i := strings.Index(s, "."); i >= 0 && (i == len(s)-1 || s[i+1] == 'e' || s[i+1] == 'E') {
+		s = s[:i+1] + "0" + s[i+1:]
+	}
+	return s
+}
+
+func quoteCELString(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + r.Replace(s) + `"`
+}
@@ -0,0 +1,167 @@
+package rqlParser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type CELTest struct {
+	Name           string // Name of the test
+	CEL            string // Input CEL expression
+	RQL            string // Equivalent RQL query producing the same tree
+	Formatted      string // Expected output of FormatCEL (the round trip is always checked)
+	WantParseError bool   // Test should raise an error when parsing the expression
+}
+
+func (test *CELTest) Run(t *testing.T) {
+	root, err := NewParser().ParseCEL(test.CEL)
+	if test.WantParseError != (err != nil) {
+		t.Fatalf("(%s) Expecting error :%v\nGot error : %v", test.Name, test.WantParseError, err)
+	}
+	if err != nil {
+		return
+	}
+
+	rqlRoot, err := NewParser().Parse(strings.NewReader(test.RQL))
+	if err != nil {
+		t.Fatalf("(%s) Unexpected RQL parse error : %v", test.Name, err)
+	}
+	if !reflect.DeepEqual(root.Node, rqlRoot.Node) {
+		t.Fatalf("(%s) CEL tree doesn’t match the RQL one %v vs %v", test.Name, root.Node, rqlRoot.Node)
+	}
+
+	expr, err := FormatCEL(root.Node)
+	if err != nil {
+		t.Fatalf("(%s) Unexpected format error : %v", test.Name, err)
+	}
+	if expr != test.Formatted {
+		t.Fatalf("(%s) Formatted expression doesn’t match the expected one %s vs %s", test.Name, expr, test.Formatted)
+	}
+	roundTrip, err := NewParser().ParseCEL(expr)
+	if err != nil {
+		t.Fatalf("(%s) Unexpected error parsing the formatted expression %s : %v", test.Name, expr, err)
+	}
+	if !reflect.DeepEqual(root.Node, roundTrip.Node) {
+		t.Fatalf("(%s) Round trip doesn’t match %v vs %v", test.Name, roundTrip.Node, root.Node)
+	}
+}
+
+var celTests = []CELTest{
+	{
+		Name:      `Comparisons and literals`,
+		CEL:       `price > 10 && status == "open" && deleted == false && owner != null`,
+		RQL:       `gt(price,10)&eq(status,"open")&eq(deleted,false)&ne(owner,null)`,
+		Formatted: `price > 10 && status == "open" && deleted == false && owner != null`,
+	},
+	{
+		Name:      `Orderings`,
+		CEL:       `a<1||a<=-2.5||a>=3e2`,
+		RQL:       `lt(a,1)|le(a,-2.5)|ge(a,3e2)`,
+		Formatted: `a < 1 || a <= -2.5 || a >= 3e2`,
+	},
+	{
+		Name:      `Precedence of && over || and parenthesis`,
+		CEL:       `a == 1 || b == 2 && (c == 3 || !(d == 4))`,
+		RQL:       `eq(a,1)|(eq(b,2)&(eq(c,3)|not(eq(d,4))))`,
+		Formatted: `a == 1 || (b == 2 && (c == 3 || !(d == 4)))`,
+	},
+	{
+		Name:      `String methods`,
+		CEL:       `name.startsWith("Chris") && mail.endsWith('@x.com') && user.bio.contains("go")`,
+		RQL:       `like(name,"Chris*")&like(mail,"*@x.com")&like(user.bio,"*go*")`,
+		Formatted: `name.startsWith("Chris") && mail.endsWith("@x.com") && user.bio.contains("go")`,
+	},
+	{
+		Name:      `Lists`,
+		CEL:       `tag in ["a", 1, true] && !(type in ["x"])`,
+		RQL:       `in(tag,"a",1,true)&out(type,"x")`,
+		Formatted: `tag in ["a", 1, true] && !(type in ["x"])`,
+	},
+	{
+		Name:      `Escaped strings`,
+		CEL:       `a == "say \"hi\"\n" && b == "10"`,
+		RQL:       `eq(a,"say \"hi\"\n")&eq(b,"10")`,
+		Formatted: `a == "say \"hi\"\n" && b == "10"`,
+	},
+	{
+		Name:      `Empty expression`,
+		CEL:       ``,
+		RQL:       ``,
+		Formatted: ``,
+	},
+	{
+		Name:           `Field compared to a field`,
+		CEL:            `a == b`,
+		WantParseError: true,
+	},
+	{
+		Name:           `Unsupported method`,
+		CEL:            `a.matches("x")`,
+		WantParseError: true,
+	},
+	{
+		Name:           `Wildcard in a method`,
+		CEL:            `a.startsWith("x*")`,
+		WantParseError: true,
+	},
+	{
+		Name:           `Single ampersand`,
+		CEL:            `a == 1 & b == 2`,
+		WantParseError: true,
+	},
+	{
+		Name:           `Missing closing bracket`,
+		CEL:            `a in [1, 2`,
+		WantParseError: true,
+	},
+	{
+		Name:           `Invalid field`,
+		CEL:            `a-b == 1`,
+		WantParseError: true,
+	},
+}
+
+func TestCEL(t *testing.T) {
+	for _, test := range celTests {
+		test.Run(t)
+	}
+}
+
+func TestFormatCEL(t *testing.T) {
+	root, err := NewParser().Parse(strings.NewReader(`like(a,b)&like(c,"C*s.x")&eq(d,x)`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expr, err := FormatCEL(root.Node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `a == "b" && c.matches("^C.*s\\.x$") && d == "x"`; expr != expected {
+		t.Fatalf("Formatted expression doesn’t match the expected one %s vs %s", expr, expected)
+	}
+
+	root, err = NewParser().Parse(strings.NewReader(`eq(a,%2B1)&eq(b,1.)&eq(c,-2.e3)&eq(d,.5)`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expr, err = FormatCEL(root.Node); err != nil {
+		t.Fatal(err)
+	}
+	if expected := `a == 1 && b == 1.0 && c == -2.0e3 && d == .5`; expr != expected {
+		t.Fatalf("Formatted numbers don’t match the expected ones %s vs %s", expr, expected)
+	}
+	if _, err = NewParser().ParseCEL(expr); err != nil {
+		t.Fatalf("Unexpected error parsing the formatted numbers %s : %v", expr, err)
+	}
+
+	for _, rql := range []string{`match(a,b)`, `eq(a-b,1)`} {
+		root, err := NewParser().Parse(strings.NewReader(rql))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = FormatCEL(root.Node); err == nil {
+			t.Fatalf("Expecting an error formatting %s", rql)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package rqlParser
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// cursor is the decoded content of a pagination cursor. The sort keys are
+// stored along the values so a cursor can't be used with another sort.
+type cursor struct {
+	Sort   []string      `json:"s"`
+	Values []interface{} `json:"v"`
+}
+
+func sortKeys(sorts []Sort) []string {
+	keys := make([]string, len(sorts))
+	for i, s := range sorts {
+		keys[i] = s.String()
+	}
+	return keys
+}
+
+// EncodeCursor returns the opaque cursor for the given sort keys and the
+// values of these keys in the last (or first) returned row
+func EncodeCursor(sorts []Sort, values ...interface{}) (string, error) {
+	if len(sorts) == 0 {
+		return "", fmt.Errorf("Cursor requires at least one sort key")
+	}
+	if len(sorts) != len(values) {
+		return "", fmt.Errorf("Cursor requires %d values (got %d)", len(sorts), len(values))
+	}
+
+	b, err := json.Marshal(cursor{Sort: sortKeys(sorts), Values: values})
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// DecodeCursor returns the values stored in the cursor. An error is returned
+// if the cursor was not encoded for the given sort keys.
+// Numbers are returned as json.Number.
+func DecodeCursor(s string, sorts []Sort) ([]interface{}, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid cursor : %s", err)
+	}
+
+	var c cursor
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err = d.Decode(&c); err != nil {
+		return nil, fmt.Errorf("Invalid cursor : %s", err)
+	}
+
+	keys := sortKeys(sorts)
+	if len(c.Sort) != len(keys) || len(c.Values) != len(keys) {
+		return nil, fmt.Errorf("Cursor doesn't match the current sort")
+	}
+	for i, k := range keys {
+		if c.Sort[i] != k {
+			return nil, fmt.Errorf("Cursor doesn't match the current sort")
+		}
+	}
+
+	return c.Values, nil
+}
+
+// EncodeCursor returns the cursor for the sort of the root node (see EncodeCursor).
+// The default sort of a translator isn't included, use SqlTranslator.EncodeCursor
+// when one is set.
+func (r *RqlRootNode) EncodeCursor(values ...interface{}) (string, error) {
+	return EncodeCursor(r.sorts, values...)
+}
+
+// CursorValues returns the values of the after() or before() cursor. The
+// values are nil when the query has no cursor.
+func (r *RqlRootNode) CursorValues() ([]interface{}, error) {
+	if r.after != "" {
+		return DecodeCursor(r.after, r.sorts)
+	} else if r.before != "" {
+		return DecodeCursor(r.before, r.sorts)
+	}
+	return nil, nil
+}
@@ -0,0 +1,127 @@
+package rqlParser
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type CursorTest struct {
+	Name                string        // Name of the test
+	RQL                 string        // Input RQL query, %s is replaced by the cursor
+	CursorSort          []Sort        // Sort used to encode the cursor
+	CursorValues        []interface{} // Values stored in the cursor
+	SQL                 string        // Expected Output SQL
+	WantTranslatorError bool          // Test should raise an error when translating to SQL
+}
+
+func (test *CursorTest) Run(t *testing.T) {
+	cursor, err := EncodeCursor(test.CursorSort, test.CursorValues...)
+	if err != nil {
+		t.Fatalf("(%s) Unexpected cursor error : %v", test.Name, err)
+	}
+
+	rqlNode, err := NewParser().Parse(strings.NewReader(strings.Replace(test.RQL, "%s", cursor, 1)))
+	if err != nil {
+		t.Fatalf("(%s) Unexpected parse error : %v", test.Name, err)
+	}
+
+	s, err := NewSqlTranslator(rqlNode).Sql()
+	if test.WantTranslatorError != (err != nil) {
+		t.Fatalf("(%s) Expecting error :%v\nGot error : %v \n\tSQL = %s", test.Name, test.WantTranslatorError, err, s)
+	}
+
+	if s != test.SQL {
+		t.Fatalf("(%s) Translated SQL doesn’t match the expected one %s vs %s", test.Name, s, test.SQL)
+	}
+}
+
+var cursorTests = []CursorTest{
+	{
+		Name:         `After cursor with a single sort key`,
+		RQL:          `eq(foo,42)&sort(-price)&after(%s)&limit(10)`,
+		CursorSort:   []Sort{{by: `price`, desc: true}},
+		CursorValues: []interface{}{10},
+		SQL:          `WHERE (foo = 42) AND (price < 10) ORDER BY price DESC LIMIT 10`,
+	},
+	{
+		Name:         `After cursor with same direction sort keys`,
+		RQL:          `sort(+date,+id)&after(%s)`,
+		CursorSort:   []Sort{{by: `date`}, {by: `id`}},
+		CursorValues: []interface{}{`2019-01-05`, 42},
+		SQL:          `WHERE ((date, id) > ('2019-01-05', 42)) ORDER BY date, id`,
+	},
+	{
+		Name:         `Before cursor with mixed direction sort keys`,
+		RQL:          `sort(-price,+id)&before(%s)`,
+		CursorSort:   []Sort{{by: `price`, desc: true}, {by: `id`}},
+		CursorValues: []interface{}{10, 42},
+		SQL:          `WHERE ((price > 10) OR (price = 10 AND id < 42)) ORDER BY price, id DESC`,
+	},
+	{
+		Name:                `Cursor encoded for another sort`,
+		RQL:                 `sort(+price)&after(%s)`,
+		CursorSort:          []Sort{{by: `date`}},
+		CursorValues:        []interface{}{`2019-01-05`},
+		WantTranslatorError: true,
+	},
+}
+
+func TestCursor(t *testing.T) {
+	for _, test := range cursorTests {
+		test.Run(t)
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	root, err := NewParser().Parse(strings.NewReader(`sort(+name,-id)`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cursor, err := root.EncodeCursor(`foo`, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := DecodeCursor(cursor, root.Sort())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 2 || values[0] != `foo` || values[1] != json.Number(`42`) {
+		t.Fatalf("Decoded cursor values don’t match the encoded ones : %v", values)
+	}
+}
+
+func TestCursorDefaultSort(t *testing.T) {
+	root, err := NewParser().Parse(strings.NewReader(`sort(-price)&limit(20)`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := NewSqlTranslator(root)
+	st.SetDefaultSort("+id")
+
+	// The cursor holds the default sort key too
+	cursor, err := st.EncodeCursor(10, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = root.EncodeCursor(10, 42); err == nil {
+		t.Fatalf("Expecting an error as the root node ignores the default sort")
+	}
+
+	root, err = NewParser().Parse(strings.NewReader(`sort(-price)&after(` + cursor + `)&limit(20)`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	st = NewSqlTranslator(root)
+	st.SetDefaultSort("+id")
+
+	s, err := st.Sql()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `WHERE ((price < 10) OR (price = 10 AND id > 42)) ORDER BY price DESC, id LIMIT 20`; s != expected {
+		t.Fatalf("Translated SQL doesn’t match the expected one %s vs %s", s, expected)
+	}
+}
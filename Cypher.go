@@ -0,0 +1,301 @@
+package rqlParser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type CypherTranslator struct {
+	rootNode *RqlRootNode
+	variable string
+	opsDic   map[string]TranslatorOpFunc
+	opSpecs  *OpSpecs
+	params   map[string]interface{}
+}
+
+// NewCypherTranslator returns a translator prefixing the fields with the
+// variable of the matched node (eg: n for MATCH (n:Person))
+func NewCypherTranslator(r *RqlRootNode, variable string) (ct *CypherTranslator) {
+	ct = &CypherTranslator{rootNode: r, variable: variable, opsDic: map[string]TranslatorOpFunc{}, opSpecs: NewOpSpecs(), params: map[string]interface{}{}}
+
+	ct.SetOpFunc("AND", ct.GetAndOrTranslatorOpFunc("AND"))
+	ct.SetOpFunc("OR", ct.GetAndOrTranslatorOpFunc("OR"))
+	ct.SetOpFunc("NOT", ct.GetNotTranslatorOpFunc())
+
+	ct.SetOpFunc("EQ", ct.GetComparisonTranslatorOpFunc("=", "IS NULL"))
+	ct.SetOpFunc("NE", ct.GetComparisonTranslatorOpFunc("<>", "IS NOT NULL"))
+	ct.SetOpFunc("LT", ct.GetComparisonTranslatorOpFunc("<", ""))
+	ct.SetOpFunc("LE", ct.GetComparisonTranslatorOpFunc("<=", ""))
+	ct.SetOpFunc("GT", ct.GetComparisonTranslatorOpFunc(">", ""))
+	ct.SetOpFunc("GE", ct.GetComparisonTranslatorOpFunc(">=", ""))
+	ct.SetOpFunc("LIKE", ct.GetLikeTranslatorOpFunc(false))
+	ct.SetOpFunc("MATCH", ct.GetLikeTranslatorOpFunc(true))
+	ct.SetOpFunc("IN", ct.GetInTranslatorOpFunc(false))
+	ct.SetOpFunc("OUT", ct.GetInTranslatorOpFunc(true))
+
+	return
+}
+
+func (ct *CypherTranslator) SetOpFunc(op string, f TranslatorOpFunc) {
+	ct.opsDic[strings.ToUpper(op)] = f
+}
+
+// DeleteOpFunc removes an operator, its OpSpec is removed too
+func (ct *CypherTranslator) DeleteOpFunc(op string) {
+	delete(ct.opsDic, strings.ToUpper(op))
+	ct.opSpecs.Delete(op)
+}
+
+// SetOpSpecs sets the registry used to validate the operators arguments, nil
+// disables the validation. The registry is copied so DeleteOpFunc doesn't
+// change the given one (eg: the registry of the Parser).
+func (ct *CypherTranslator) SetOpSpecs(specs *OpSpecs) {
+	ct.opSpecs = specs.Clone()
+}
+
+// Params returns the values of the $p0, $p1... placeholders of the last translation
+func (ct *CypherTranslator) Params() map[string]interface{} {
+	return ct.params
+}
+
+// Where returns the condition of the WHERE clause (without WHERE), the values are placeholders (see Params)
+func (ct *CypherTranslator) Where() (string, error) {
+	ct.params = map[string]interface{}{}
+
+	if ct.rootNode == nil || ct.rootNode.Node == nil {
+		return "", nil
+	}
+	if err := ct.opSpecs.Validate(ct.rootNode.Node); err != nil {
+		return "", err
+	}
+	return ct.where(ct.rootNode.Node)
+}
+
+func (ct *CypherTranslator) where(n *RqlNode) (string, error) {
+	f := ct.opsDic[strings.ToUpper(n.Op)]
+	if f == nil {
+		return "", fmt.Errorf("No TranslatorOpFunc for op : '%s'", n.Op)
+	}
+	return f(n)
+}
+
+// OrderBy returns the ORDER BY clause
+func (ct *CypherTranslator) OrderBy() (string, error) {
+	if ct.rootNode == nil || len(ct.rootNode.Sort()) == 0 {
+		return "", nil
+	}
+
+	keys := make([]string, len(ct.rootNode.Sort()))
+	for i, s := range ct.rootNode.Sort() {
+		property, err := ct.property(s.By())
+		if err != nil {
+			return "", err
+		}
+		keys[i] = property
+		if s.Desc() {
+			keys[i] += " DESC"
+		}
+	}
+	return " ORDER BY " + strings.Join(keys, ", "), nil
+}
+
+// SkipLimit returns the SKIP and LIMIT clauses
+func (ct *CypherTranslator) SkipLimit() string {
+	if ct.rootNode == nil {
+		return ""
+	}
+	s := ""
+	if ct.rootNode.Offset() != "" {
+		s += " SKIP " + ct.rootNode.Offset()
+	}
+	if ct.rootNode.Limit() != "" && ct.rootNode.LimitInt() != InfiniteLimit {
+		s += " LIMIT " + ct.rootNode.Limit()
+	}
+	return s
+}
+
+// Cypher returns the WHERE, ORDER BY, SKIP and LIMIT clauses following the MATCH clause
+func (ct *CypherTranslator) Cypher() (string, error) {
+	where, err := ct.Where()
+	if err != nil {
+		return "", err
+	}
+	if where != "" {
+		where = "WHERE " + where
+	}
+
+	orderBy, err := ct.OrderBy()
+	if err != nil {
+		return "", err
+	}
+
+	return where + orderBy + ct.SkipLimit(), nil
+}
+
+// cypherIdentifier matches the identifiers which don't require backquotes
+var cypherIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// property returns the property of the node variable (eg: n.name), each
+// dotted segment is a map key
+func (ct *CypherTranslator) property(arg interface{}) (string, error) {
+	field, ok := arg.(string)
+	if !ok || !IsValidField(field) {
+		return "", fmt.Errorf("Invalid field name : %v", arg)
+	}
+
+	segments := strings.Split(field, ".")
+	for i, s := range segments {
+		if !cypherIdentifier.MatchString(s) {
+			segments[i] = "`" + s + "`"
+		}
+	}
+	return ct.variable + "." + strings.Join(segments, "."), nil
+}
+
+// param returns the placeholder of a new parameter
+func (ct *CypherTranslator) param(value interface{}) string {
+	name := "p" + strconv.Itoa(len(ct.params))
+	ct.params[name] = value
+	return "$" + name
+}
+
+// propertyValue returns the property and the value of a comparison
+func (ct *CypherTranslator) propertyValue(n *RqlNode) (string, interface{}, error) {
+	if len(n.Args) != 2 {
+		return "", nil, &ArgumentError{Op: n.Op, Reason: "requires 2 arguments"}
+	}
+	property, err := ct.property(n.Args[0])
+	if err != nil {
+		return "", nil, err
+	}
+	switch n.Args[1].(type) {
+	case string, StringLiteral:
+	default:
+		return "", nil, &ArgumentError{Op: n.Op, Arg: n.Args[1], Reason: "must be a value"}
+	}
+	return property, n.Args[1], nil
+}
+
+func (ct *CypherTranslator) GetAndOrTranslatorOpFunc(op string) TranslatorOpFunc {
+	return TranslatorOpFunc(func(n *RqlNode) (string, error) {
+		if len(n.Args) == 0 {
+			return "", &ArgumentError{Op: n.Op, Reason: "requires at least 1 argument"}
+		}
+
+		exprs := make([]string, len(n.Args))
+		for i, a := range n.Args {
+			c, ok := a.(*RqlNode)
+			if !ok {
+				return "", &ArgumentError{Op: n.Op, Arg: a, Reason: "must be an operator"}
+			}
+			s, err := ct.where(c)
+			if err != nil {
+				return "", err
+			}
+			exprs[i] = s
+		}
+
+		return "(" + strings.Join(exprs, " "+op+" ") + ")", nil
+	})
+}
+
+func (ct *CypherTranslator) GetNotTranslatorOpFunc() TranslatorOpFunc {
+	return TranslatorOpFunc(func(n *RqlNode) (string, error) {
+		if len(n.Args) != 1 {
+			return "", &ArgumentError{Op: n.Op, Reason: "requires 1 argument"}
+		}
+		c, ok := n.Args[0].(*RqlNode)
+		if !ok {
+			return "", &ArgumentError{Op: n.Op, Arg: n.Args[0], Reason: "must be an operator"}
+		}
+		s, err := ct.where(c)
+		if err != nil {
+			return "", err
+		}
+		return "NOT " + s, nil
+	})
+}
+
+// GetComparisonTranslatorOpFunc returns a comparison, nullOp is used for the
+// comparison to null (eg: IS NULL) and can't be empty
+func (ct *CypherTranslator) GetComparisonTranslatorOpFunc(op, nullOp string) TranslatorOpFunc {
+	return TranslatorOpFunc(func(n *RqlNode) (string, error) {
+		property, value, err := ct.propertyValue(n)
+		if err != nil {
+			return "", err
+		}
+		v := nativeValue(value)
+		if v == nil {
+			if nullOp == "" {
+				return "", &ArgumentError{Op: n.Op, Arg: value, Reason: "can't be compared"}
+			}
+			return "(" + property + " " + nullOp + ")", nil
+		}
+		return "(" + property + " " + op + " " + ct.param(v) + ")", nil
+	})
+}
+
+// GetLikeTranslatorOpFunc returns STARTS WITH (abc*), ENDS WITH (*abc),
+// CONTAINS (*abc*) or a regular expression for the other patterns. When
+// ignoreCase is true, the pattern is always a case insensitive regular expression.
+func (ct *CypherTranslator) GetLikeTranslatorOpFunc(ignoreCase bool) TranslatorOpFunc {
+	return TranslatorOpFunc(func(n *RqlNode) (string, error) {
+		property, value, err := ct.propertyValue(n)
+		if err != nil {
+			return "", err
+		}
+		pattern := fmt.Sprint(value)
+		inner := strings.TrimSuffix(strings.TrimPrefix(pattern, "*"), "*")
+
+		if !ignoreCase && inner != "" && !strings.Contains(inner, "*") {
+			switch {
+			case len(pattern) > len(inner)+1:
+				return "(" + property + " CONTAINS " + ct.param(inner) + ")", nil
+			case strings.HasSuffix(pattern, "*"):
+				return "(" + property + " STARTS WITH " + ct.param(inner) + ")", nil
+			case strings.HasPrefix(pattern, "*"):
+				return "(" + property + " ENDS WITH " + ct.param(inner) + ")", nil
+			}
+		}
+
+		parts := strings.Split(pattern, "*")
+		for i, p := range parts {
+			parts[i] = regexp.QuoteMeta(p)
+		}
+		regex := strings.Join(parts, ".*")
+		if ignoreCase {
+			regex = "(?i)" + regex
+		}
+		return "(" + property + " =~ " + ct.param(regex) + ")", nil
+	})
+}
+
+func (ct *CypherTranslator) GetInTranslatorOpFunc(negated bool) TranslatorOpFunc {
+	return TranslatorOpFunc(func(n *RqlNode) (string, error) {
+		if len(n.Args) < 2 {
+			return "", &ArgumentError{Op: n.Op, Reason: "requires at least 2 arguments"}
+		}
+		property, err := ct.property(n.Args[0])
+		if err != nil {
+			return "", err
+		}
+
+		values := make([]interface{}, len(n.Args)-1)
+		for i, a := range n.Args[1:] {
+			switch a.(type) {
+			case string, StringLiteral:
+				values[i] = nativeValue(a)
+			default:
+				return "", &ArgumentError{Op: n.Op, Arg: a, Reason: "must be a value"}
+			}
+		}
+
+		s := "(" + property + " IN " + ct.param(values) + ")"
+		if negated {
+			s = "NOT " + s
+		}
+		return s, nil
+	})
+}
@@ -0,0 +1,93 @@
+package rqlParser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type CypherTest struct {
+	Name                string                 // Name of the test
+	RQL                 string                 // Input RQL query
+	Cypher              string                 // Expected Cypher clauses
+	Params              map[string]interface{} // Expected parameters
+	WantTranslatorError bool                   // Test should raise an error when translating
+}
+
+func (test *CypherTest) Run(t *testing.T) {
+	root, err := NewParser().Parse(strings.NewReader(test.RQL))
+	if err != nil {
+		t.Fatalf("(%s) Unexpected parse error : %v", test.Name, err)
+	}
+
+	ct := NewCypherTranslator(root, "n")
+	s, err := ct.Cypher()
+	if test.WantTranslatorError != (err != nil) {
+		t.Fatalf("(%s) Expecting error :%v\nGot error : %v", test.Name, test.WantTranslatorError, err)
+	}
+	if err != nil {
+		return
+	}
+
+	if s != test.Cypher {
+		t.Fatalf("(%s) Translated Cypher doesn’t match the expected one %q vs %q", test.Name, s, test.Cypher)
+	}
+	if !reflect.DeepEqual(ct.Params(), test.Params) {
+		t.Fatalf("(%s) Parameters don’t match the expected ones %v vs %v", test.Name, ct.Params(), test.Params)
+	}
+}
+
+var cypherTests = []CypherTest{
+	{
+		Name:   `Comparisons, sort, skip and limit`,
+		RQL:    `eq(name,Alice)&gt(age,30)&sort(-age,+name)&limit(20,40)`,
+		Cypher: `WHERE ((n.name = $p0) AND (n.age > $p1)) ORDER BY n.age DESC, n.name SKIP 40 LIMIT 20`,
+		Params: map[string]interface{}{"p0": "Alice", "p1": int64(30)},
+	},
+	{
+		Name:   `Logical operators and null`,
+		RQL:    `or(and(le(a,1.5),ne(b,x)),not(eq(c,null)),ne(d,null),eq(e,"true"))`,
+		Cypher: `WHERE (((n.a <= $p0) AND (n.b <> $p1)) OR NOT (n.c IS NULL) OR (n.d IS NOT NULL) OR (n.e = $p2))`,
+		Params: map[string]interface{}{"p0": 1.5, "p1": "x", "p2": "true"},
+	},
+	{
+		Name:   `Like and match`,
+		RQL:    `like(a,Chris*)&like(b,*son)&like(c,*ris*)&like(d,C*s.x)&match(e,*abc*)`,
+		Cypher: `WHERE ((n.a STARTS WITH $p0) AND (n.b ENDS WITH $p1) AND (n.c CONTAINS $p2) AND (n.d =~ $p3) AND (n.e =~ $p4))`,
+		Params: map[string]interface{}{"p0": "Chris", "p1": "son", "p2": "ris", "p3": `C.*s\.x`, "p4": "(?i).*abc.*"},
+	},
+	{
+		Name:   `Values with leading zeros`,
+		RQL:    `eq(zip,01234)&in(code,007,7,0)`,
+		Cypher: `WHERE ((n.zip = $p0) AND (n.code IN $p1))`,
+		Params: map[string]interface{}{"p0": "01234", "p1": []interface{}{"007", int64(7), int64(0)}},
+	},
+	{
+		Name:   `Lists and property paths`,
+		RQL:    `in(address.city,Paris,42)&out(my-tag,a)&sort(+address.city)&limit(Infinity)`,
+		Cypher: "WHERE ((n.address.city IN $p0) AND NOT (n.`my-tag` IN $p1)) ORDER BY n.address.city",
+		Params: map[string]interface{}{"p0": []interface{}{"Paris", int64(42)}, "p1": []interface{}{"a"}},
+	},
+	{
+		Name:   `Empty query`,
+		RQL:    ``,
+		Cypher: ``,
+		Params: map[string]interface{}{},
+	},
+	{
+		Name:                `Comparison to null`,
+		RQL:                 `gt(a,null)`,
+		WantTranslatorError: true,
+	},
+	{
+		Name:                `Unsupported operator`,
+		RQL:                 `contains(a,b)`,
+		WantTranslatorError: true,
+	},
+}
+
+func TestCypherTranslator(t *testing.T) {
+	for _, test := range cypherTests {
+		test.Run(t)
+	}
+}
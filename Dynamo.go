@@ -0,0 +1,404 @@
+package rqlParser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DynamoExpression holds the expressions and the placeholders of a DynamoDB
+// Query or Scan input. The values are plain Go values (string, int64,
+// float64 or bool) to be marshalled with the SDK.
+type DynamoExpression struct {
+	KeyConditionExpression    string
+	FilterExpression          string
+	ExpressionAttributeNames  map[string]string      // Placeholder (eg: #n0) to attribute name
+	ExpressionAttributeValues map[string]interface{} // Placeholder (eg: :v0) to value
+}
+
+type DynamoTranslator struct {
+	rootNode  *RqlRootNode
+	opsDic    map[string]TranslatorOpFunc
+	opSpecs   *OpSpecs
+	expr      *DynamoExpression
+	nameIndex map[string]string
+}
+
+func NewDynamoTranslator(r *RqlRootNode) (dt *DynamoTranslator) {
+	dt = &DynamoTranslator{rootNode: r, opsDic: map[string]TranslatorOpFunc{}, opSpecs: NewOpSpecs()}
+
+	dt.SetOpFunc("AND", dt.GetAndOrTranslatorOpFunc("AND"))
+	dt.SetOpFunc("OR", dt.GetAndOrTranslatorOpFunc("OR"))
+	dt.SetOpFunc("NOT", dt.GetNotTranslatorOpFunc())
+
+	dt.SetOpFunc("EQ", dt.GetComparisonTranslatorOpFunc("="))
+	dt.SetOpFunc("NE", dt.GetComparisonTranslatorOpFunc("<>"))
+	dt.SetOpFunc("LT", dt.GetComparisonTranslatorOpFunc("<"))
+	dt.SetOpFunc("LE", dt.GetComparisonTranslatorOpFunc("<="))
+	dt.SetOpFunc("GT", dt.GetComparisonTranslatorOpFunc(">"))
+	dt.SetOpFunc("GE", dt.GetComparisonTranslatorOpFunc(">="))
+	dt.SetOpFunc("LIKE", dt.GetLikeTranslatorOpFunc())
+	dt.SetOpFunc("CONTAINS", dt.GetFunctionTranslatorOpFunc("contains"))
+	dt.SetOpFunc("IN", dt.GetInTranslatorOpFunc(false))
+	dt.SetOpFunc("OUT", dt.GetInTranslatorOpFunc(true))
+
+	return
+}
+
+func (dt *DynamoTranslator) SetOpFunc(op string, f TranslatorOpFunc) {
+	dt.opsDic[strings.ToUpper(op)] = f
+}
+
+// DeleteOpFunc removes an operator, its OpSpec is removed too
+func (dt *DynamoTranslator) DeleteOpFunc(op string) {
+	delete(dt.opsDic, strings.ToUpper(op))
+	dt.opSpecs.Delete(op)
+}
+
+// SetOpSpecs sets the registry used to validate the operators arguments, nil
+// disables the validation. The registry is copied so DeleteOpFunc doesn't
+// change the given one (eg: the registry of the Parser).
+func (dt *DynamoTranslator) SetOpSpecs(specs *OpSpecs) {
+	dt.opSpecs = specs.Clone()
+}
+
+// FilterExpression returns the query as a FilterExpression
+func (dt *DynamoTranslator) FilterExpression() (*DynamoExpression, error) {
+	if err := dt.begin(); err != nil {
+		return nil, err
+	}
+
+	if dt.node() != nil {
+		s, err := dt.where(dt.node())
+		if err != nil {
+			return nil, err
+		}
+		dt.expr.FilterExpression = s
+	}
+
+	return dt.expr, nil
+}
+
+// KeyConditionExpression returns the query as a KeyConditionExpression and a
+// FilterExpression. The top level conditions on the keys make the key
+// condition : an equality on the partition key and an optional comparison,
+// begins_with (like(key,abc*)) or between (ge and le) on the sort key. The
+// other conditions make the filter, which can't use the keys.
+func (dt *DynamoTranslator) KeyConditionExpression(partitionKey, sortKey string) (*DynamoExpression, error) {
+	if err := dt.begin(); err != nil {
+		return nil, err
+	}
+
+	var (
+		conditions   []interface{}
+		partition    *RqlNode
+		sortKeyConds []*RqlNode
+		filters      []interface{}
+	)
+
+	if n := dt.node(); n != nil && strings.ToUpper(n.Op) == "AND" {
+		conditions = n.Args
+	} else if n != nil {
+		conditions = []interface{}{n}
+	}
+
+	for _, c := range conditions {
+		n, ok := c.(*RqlNode)
+		if !ok || len(n.Args) == 0 {
+			filters = append(filters, c)
+			continue
+		}
+		switch field, _ := n.Args[0].(string); {
+		case field == partitionKey && partition == nil && strings.ToUpper(n.Op) == "EQ":
+			partition = n
+		case field == sortKey && sortKey != "":
+			sortKeyConds = append(sortKeyConds, n)
+		default:
+			filters = append(filters, c)
+		}
+	}
+
+	if partition == nil || len(partition.Args) != 2 || nativeValue(partition.Args[1]) == nil {
+		return nil, fmt.Errorf("Missing equality on the partition key %s", partitionKey)
+	}
+
+	key, err := dt.where(partition)
+	if err != nil {
+		return nil, err
+	}
+	if len(sortKeyConds) > 0 {
+		s, err := dt.sortKeyCondition(sortKeyConds)
+		if err != nil {
+			return nil, err
+		}
+		key += " AND " + s
+	}
+	dt.expr.KeyConditionExpression = key
+
+	if len(filters) > 0 {
+		filter := &RqlNode{Op: "AND", Args: filters}
+		if n, ok := filters[0].(*RqlNode); ok && len(filters) == 1 {
+			filter = n
+		}
+		if dt.usesKeys(filter, partitionKey, sortKey) {
+			return nil, fmt.Errorf("The filter can't use the keys %s and %s", partitionKey, sortKey)
+		}
+		if dt.expr.FilterExpression, err = dt.where(filter); err != nil {
+			return nil, err
+		}
+	}
+
+	return dt.expr, nil
+}
+
+// sortKeyCondition returns the key condition on the sort key
+func (dt *DynamoTranslator) sortKeyCondition(conds []*RqlNode) (string, error) {
+	if len(conds) == 2 {
+		low, high := conds[0], conds[1]
+		if strings.ToUpper(low.Op) == "LE" {
+			low, high = high, low
+		}
+		if strings.ToUpper(low.Op) != "GE" || strings.ToUpper(high.Op) != "LE" || len(low.Args) != 2 || len(high.Args) != 2 {
+			return "", &ArgumentError{Op: conds[1].Op, Arg: conds[1].Args[0], Reason: "only a ge and a le condition can be combined on the sort key"}
+		}
+		name, err := dt.name(low.Args[0])
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s BETWEEN %s AND %s", name, dt.value(low.Args[1]), dt.value(high.Args[1])), nil
+	}
+	if len(conds) > 2 {
+		return "", &ArgumentError{Op: conds[2].Op, Arg: conds[2].Args[0], Reason: "too many conditions on the sort key"}
+	}
+
+	switch strings.ToUpper(conds[0].Op) {
+	case "EQ", "LT", "LE", "GT", "GE":
+		if nativeValue(conds[0].Args[len(conds[0].Args)-1]) == nil {
+			break
+		}
+		return dt.where(conds[0])
+	case "LIKE":
+		s, err := dt.where(conds[0])
+		if err == nil && !strings.HasPrefix(s, "begins_with(") {
+			err = &ArgumentError{Op: conds[0].Op, Arg: conds[0].Args[1], Reason: "only a prefix can be used on the sort key"}
+		}
+		return s, err
+	}
+	return "", &ArgumentError{Op: conds[0].Op, Arg: conds[0].Args[0], Reason: "can't be used on the sort key"}
+}
+
+// usesKeys reports whether a node has a condition on the keys
+func (dt *DynamoTranslator) usesKeys(n *RqlNode, keys ...string) bool {
+	if len(n.Args) == 0 {
+		return false
+	}
+	if field, ok := n.Args[0].(string); ok {
+		for _, k := range keys {
+			if k != "" && field == k {
+				return true
+			}
+		}
+	}
+	for _, a := range n.Args {
+		if c, ok := a.(*RqlNode); ok && dt.usesKeys(c, keys...) {
+			return true
+		}
+	}
+	return false
+}
+
+// node returns the query node, nil when there is no root node
+func (dt *DynamoTranslator) node() *RqlNode {
+	if dt.rootNode == nil {
+		return nil
+	}
+	return dt.rootNode.Node
+}
+
+func (dt *DynamoTranslator) begin() error {
+	if err := dt.opSpecs.Validate(dt.node()); err != nil {
+		return err
+	}
+	dt.expr = &DynamoExpression{ExpressionAttributeNames: map[string]string{}, ExpressionAttributeValues: map[string]interface{}{}}
+	dt.nameIndex = map[string]string{}
+	return nil
+}
+
+func (dt *DynamoTranslator) where(n *RqlNode) (string, error) {
+	f := dt.opsDic[strings.ToUpper(n.Op)]
+	if f == nil {
+		return "", fmt.Errorf("No TranslatorOpFunc for op : '%s'", n.Op)
+	}
+	return f(n)
+}
+
+// name returns the placeholder of an attribute path, each dotted segment has its own placeholder
+func (dt *DynamoTranslator) name(arg interface{}) (string, error) {
+	field, ok := arg.(string)
+	if !ok || !IsValidField(field) {
+		return "", fmt.Errorf("Invalid field name : %v", arg)
+	}
+
+	segments := strings.Split(field, ".")
+	for i, s := range segments {
+		placeholder, ok := dt.nameIndex[s]
+		if !ok {
+			placeholder = "#n" + strconv.Itoa(len(dt.nameIndex))
+			dt.nameIndex[s] = placeholder
+			dt.expr.ExpressionAttributeNames[placeholder] = s
+		}
+		segments[i] = placeholder
+	}
+	return strings.Join(segments, "."), nil
+}
+
+// value returns the placeholder of a new value
+func (dt *DynamoTranslator) value(arg interface{}) string {
+	placeholder := ":v" + strconv.Itoa(len(dt.expr.ExpressionAttributeValues))
+	dt.expr.ExpressionAttributeValues[placeholder] = nativeValue(arg)
+	return placeholder
+}
+
+// fieldValue returns the placeholders of the field and the value of a comparison
+func (dt *DynamoTranslator) fieldValue(n *RqlNode) (name string, value interface{}, err error) {
+	if len(n.Args) != 2 {
+		return "", nil, &ArgumentError{Op: n.Op, Reason: "requires 2 arguments"}
+	}
+	if name, err = dt.name(n.Args[0]); err != nil {
+		return "", nil, err
+	}
+	switch n.Args[1].(type) {
+	case string, StringLiteral:
+	default:
+		return "", nil, &ArgumentError{Op: n.Op, Arg: n.Args[1], Reason: "must be a value"}
+	}
+	return name, n.Args[1], nil
+}
+
+func (dt *DynamoTranslator) GetAndOrTranslatorOpFunc(op string) TranslatorOpFunc {
+	return TranslatorOpFunc(func(n *RqlNode) (string, error) {
+		if len(n.Args) == 0 {
+			return "", &ArgumentError{Op: n.Op, Reason: "requires at least 1 argument"}
+		}
+
+		exprs := make([]string, len(n.Args))
+		for i, a := range n.Args {
+			c, ok := a.(*RqlNode)
+			if !ok {
+				return "", &ArgumentError{Op: n.Op, Arg: a, Reason: "must be an operator"}
+			}
+			s, err := dt.where(c)
+			if err != nil {
+				return "", err
+			}
+			exprs[i] = s
+		}
+
+		return "(" + strings.Join(exprs, " "+op+" ") + ")", nil
+	})
+}
+
+func (dt *DynamoTranslator) GetNotTranslatorOpFunc() TranslatorOpFunc {
+	return TranslatorOpFunc(func(n *RqlNode) (string, error) {
+		if len(n.Args) != 1 {
+			return "", &ArgumentError{Op: n.Op, Reason: "requires 1 argument"}
+		}
+		c, ok := n.Args[0].(*RqlNode)
+		if !ok {
+			return "", &ArgumentError{Op: n.Op, Arg: n.Args[0], Reason: "must be an operator"}
+		}
+		s, err := dt.where(c)
+		if err != nil {
+			return "", err
+		}
+		return "(NOT " + s + ")", nil
+	})
+}
+
+// GetComparisonTranslatorOpFunc returns a comparison, the equality to null
+// is attribute_not_exists and the inequality attribute_exists
+func (dt *DynamoTranslator) GetComparisonTranslatorOpFunc(op string) TranslatorOpFunc {
+	return TranslatorOpFunc(func(n *RqlNode) (string, error) {
+		name, value, err := dt.fieldValue(n)
+		if err != nil {
+			return "", err
+		}
+		if nativeValue(value) == nil {
+			switch op {
+			case "=":
+				return "attribute_not_exists(" + name + ")", nil
+			case "<>":
+				return "attribute_exists(" + name + ")", nil
+			}
+			return "", &ArgumentError{Op: n.Op, Arg: value, Reason: "can't be compared"}
+		}
+		return name + " " + op + " " + dt.value(value), nil
+	})
+}
+
+// GetLikeTranslatorOpFunc returns begins_with for like(x,abc*), contains for
+// like(x,*abc*) and an equality for a pattern without wildcard
+func (dt *DynamoTranslator) GetLikeTranslatorOpFunc() TranslatorOpFunc {
+	return TranslatorOpFunc(func(n *RqlNode) (string, error) {
+		name, value, err := dt.fieldValue(n)
+		if err != nil {
+			return "", err
+		}
+		pattern := fmt.Sprint(value)
+		if !strings.Contains(pattern, "*") {
+			return name + " = " + dt.value(StringLiteral(pattern)), nil
+		}
+
+		function, inner := "", ""
+		switch {
+		case len(pattern) > 2 && strings.HasPrefix(pattern, "*") && strings.HasSuffix(pattern, "*"):
+			function, inner = "contains", pattern[1:len(pattern)-1]
+		case len(pattern) > 1 && strings.HasSuffix(pattern, "*"):
+			function, inner = "begins_with", pattern[:len(pattern)-1]
+		}
+		if function == "" || strings.Contains(inner, "*") {
+			return "", &ArgumentError{Op: n.Op, Arg: value, Reason: "has no DynamoDB equivalent"}
+		}
+
+		return function + "(" + name + ", " + dt.value(StringLiteral(inner)) + ")", nil
+	})
+}
+
+// GetFunctionTranslatorOpFunc returns a function call (eg: contains(#n0, :v0))
+func (dt *DynamoTranslator) GetFunctionTranslatorOpFunc(function string) TranslatorOpFunc {
+	return TranslatorOpFunc(func(n *RqlNode) (string, error) {
+		name, value, err := dt.fieldValue(n)
+		if err != nil {
+			return "", err
+		}
+		return function + "(" + name + ", " + dt.value(value) + ")", nil
+	})
+}
+
+func (dt *DynamoTranslator) GetInTranslatorOpFunc(negated bool) TranslatorOpFunc {
+	return TranslatorOpFunc(func(n *RqlNode) (string, error) {
+		if len(n.Args) < 2 {
+			return "", &ArgumentError{Op: n.Op, Reason: "requires at least 2 arguments"}
+		}
+		name, err := dt.name(n.Args[0])
+		if err != nil {
+			return "", err
+		}
+
+		values := make([]string, len(n.Args)-1)
+		for i, a := range n.Args[1:] {
+			switch a.(type) {
+			case string, StringLiteral:
+				values[i] = dt.value(a)
+			default:
+				return "", &ArgumentError{Op: n.Op, Arg: a, Reason: "must be a value"}
+			}
+		}
+
+		s := name + " IN (" + strings.Join(values, ", ") + ")"
+		if negated {
+			s = "(NOT " + s + ")"
+		}
+		return s, nil
+	})
+}
@@ -0,0 +1,160 @@
+package rqlParser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type DynamoTest struct {
+	Name         string            // Name of the test
+	RQL          string            // Input RQL query
+	PartitionKey string            // Partition key, the FilterExpression is tested when empty
+	SortKey      string            // Sort key
+	Expected     *DynamoExpression // Expected expression, nil when an error is expected
+}
+
+func (test *DynamoTest) Run(t *testing.T) {
+	root, err := NewParser().Parse(strings.NewReader(test.RQL))
+	if err != nil {
+		t.Fatalf("(%s) Unexpected parse error : %v", test.Name, err)
+	}
+
+	var expr *DynamoExpression
+	if test.PartitionKey == "" {
+		expr, err = NewDynamoTranslator(root).FilterExpression()
+	} else {
+		expr, err = NewDynamoTranslator(root).KeyConditionExpression(test.PartitionKey, test.SortKey)
+	}
+	if (test.Expected == nil) != (err != nil) {
+		t.Fatalf("(%s) Expecting error :%v\nGot error : %v", test.Name, test.Expected == nil, err)
+	}
+	if err != nil {
+		return
+	}
+
+	if !reflect.DeepEqual(expr, test.Expected) {
+		t.Fatalf("(%s) Translated expression doesn’t match the expected one %+v vs %+v", test.Name, expr, test.Expected)
+	}
+}
+
+var dynamoTests = []DynamoTest{
+	{
+		Name: `Comparisons and typed values`,
+		RQL:  `and(eq(status,open),ne(count,0),lt(price,9.5),ge(active,true),le(code,"42"))`,
+		Expected: &DynamoExpression{
+			FilterExpression:          `(#n0 = :v0 AND #n1 <> :v1 AND #n2 < :v2 AND #n3 >= :v3 AND #n4 <= :v4)`,
+			ExpressionAttributeNames:  map[string]string{"#n0": "status", "#n1": "count", "#n2": "price", "#n3": "active", "#n4": "code"},
+			ExpressionAttributeValues: map[string]interface{}{":v0": "open", ":v1": int64(0), ":v2": 9.5, ":v3": true, ":v4": "42"},
+		},
+	},
+	{
+		Name: `Functions, null checks and nested attributes`,
+		RQL:  `or(like(name,Chris*),like(title,*draft*),contains(tags,go),eq(deleted,null),ne(owner.id,null))`,
+		Expected: &DynamoExpression{
+			FilterExpression:          `(begins_with(#n0, :v0) OR contains(#n1, :v1) OR contains(#n2, :v2) OR attribute_not_exists(#n3) OR attribute_exists(#n4.#n5))`,
+			ExpressionAttributeNames:  map[string]string{"#n0": "name", "#n1": "title", "#n2": "tags", "#n3": "deleted", "#n4": "owner", "#n5": "id"},
+			ExpressionAttributeValues: map[string]interface{}{":v0": "Chris", ":v1": "draft", ":v2": "go"},
+		},
+	},
+	{
+		Name: `Values with leading zeros`,
+		RQL:  `eq(zip,01234)&eq(n,0)`,
+		Expected: &DynamoExpression{
+			FilterExpression:          `(#n0 = :v0 AND #n1 = :v1)`,
+			ExpressionAttributeNames:  map[string]string{"#n0": "zip", "#n1": "n"},
+			ExpressionAttributeValues: map[string]interface{}{":v0": "01234", ":v1": int64(0)},
+		},
+	},
+	{
+		Name: `Like without wildcard`,
+		RQL:  `like(code,"42")`,
+		Expected: &DynamoExpression{
+			FilterExpression:          `#n0 = :v0`,
+			ExpressionAttributeNames:  map[string]string{"#n0": "code"},
+			ExpressionAttributeValues: map[string]interface{}{":v0": "42"},
+		},
+	},
+	{
+		Name: `In, out and not`,
+		RQL:  `in(a,1,x)&out(b,"y")&not(eq(a,2))`,
+		Expected: &DynamoExpression{
+			FilterExpression:          `(#n0 IN (:v0, :v1) AND (NOT #n1 IN (:v2)) AND (NOT #n0 = :v3))`,
+			ExpressionAttributeNames:  map[string]string{"#n0": "a", "#n1": "b"},
+			ExpressionAttributeValues: map[string]interface{}{":v0": int64(1), ":v1": "x", ":v2": "y", ":v3": int64(2)},
+		},
+	},
+	{
+		Name: `Empty query`,
+		RQL:  ``,
+		Expected: &DynamoExpression{
+			ExpressionAttributeNames:  map[string]string{},
+			ExpressionAttributeValues: map[string]interface{}{},
+		},
+	},
+	{
+		Name:     `Unsupported like pattern`,
+		RQL:      `like(name,a*b)`,
+		Expected: nil,
+	},
+	{
+		Name:         `Key condition with a filter`,
+		RQL:          `eq(status,open)&eq(pk,user%231)&like(sk,order*)`,
+		PartitionKey: `pk`,
+		SortKey:      `sk`,
+		Expected: &DynamoExpression{
+			KeyConditionExpression:    `#n0 = :v0 AND begins_with(#n1, :v1)`,
+			FilterExpression:          `#n2 = :v2`,
+			ExpressionAttributeNames:  map[string]string{"#n0": "pk", "#n1": "sk", "#n2": "status"},
+			ExpressionAttributeValues: map[string]interface{}{":v0": "user#1", ":v1": "order", ":v2": "open"},
+		},
+	},
+	{
+		Name:         `Key condition with between`,
+		RQL:          `le(sk,20)&eq(pk,a)&ge(sk,10)`,
+		PartitionKey: `pk`,
+		SortKey:      `sk`,
+		Expected: &DynamoExpression{
+			KeyConditionExpression:    `#n0 = :v0 AND #n1 BETWEEN :v1 AND :v2`,
+			ExpressionAttributeNames:  map[string]string{"#n0": "pk", "#n1": "sk"},
+			ExpressionAttributeValues: map[string]interface{}{":v0": "a", ":v1": int64(10), ":v2": int64(20)},
+		},
+	},
+	{
+		Name:         `Missing partition key`,
+		RQL:          `eq(sk,1)`,
+		PartitionKey: `pk`,
+		SortKey:      `sk`,
+		Expected:     nil,
+	},
+	{
+		Name:         `Unsupported sort key condition`,
+		RQL:          `eq(pk,a)&ne(sk,1)`,
+		PartitionKey: `pk`,
+		SortKey:      `sk`,
+		Expected:     nil,
+	},
+	{
+		Name:         `Key in the filter`,
+		RQL:          `eq(pk,a)&or(eq(sk,1),eq(x,2))`,
+		PartitionKey: `pk`,
+		SortKey:      `sk`,
+		Expected:     nil,
+	},
+}
+
+func TestDynamoTranslator(t *testing.T) {
+	for _, test := range dynamoTests {
+		test.Run(t)
+	}
+}
+
+func TestDynamoNilRootNode(t *testing.T) {
+	expr, err := NewDynamoTranslator(nil).FilterExpression()
+	if err != nil || expr.FilterExpression != "" {
+		t.Fatalf("Unexpected expression %+v (error : %v)", expr, err)
+	}
+	if _, err = NewDynamoTranslator(nil).KeyConditionExpression("id", ""); err == nil {
+		t.Fatalf("Expecting an error for the missing partition key")
+	}
+}
@@ -0,0 +1,120 @@
+package rqlParser
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func addFuzzSeeds(f *testing.F) {
+	for _, test := range tests {
+		f.Add(test.RQL)
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	addFuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, rql string) {
+		_, _ = NewParser().Parse(strings.NewReader(rql))
+	})
+}
+
+func FuzzSql(f *testing.F) {
+	addFuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, rql string) {
+		root, err := NewParser().Parse(strings.NewReader(rql))
+		if err != nil {
+			return
+		}
+		_, _ = NewSqlTranslator(root).Sql()
+	})
+}
+
+func FuzzParseRSQL(f *testing.F) {
+	for _, test := range rsqlTests {
+		f.Add(test.RSQL)
+	}
+	f.Fuzz(func(t *testing.T, rsql string) {
+		p := NewParser()
+		p.SetSyntax(RSQLSyntax)
+		root, err := p.Parse(strings.NewReader(rsql))
+		if err != nil {
+			return
+		}
+		_, _ = NewSqlTranslator(root).Sql()
+	})
+}
+
+func FuzzParseInfix(f *testing.F) {
+	for _, test := range infixTests {
+		f.Add(test.Infix)
+	}
+	f.Fuzz(func(t *testing.T, infix string) {
+		p := NewParser()
+		p.SetSyntax(InfixSyntax)
+		root, err := p.Parse(strings.NewReader(infix))
+		if err != nil {
+			return
+		}
+		_, _ = NewSqlTranslator(root).Sql()
+	})
+}
+
+func FuzzParseOData(f *testing.F) {
+	for _, test := range odataTests {
+		f.Add(test.Query)
+	}
+	f.Fuzz(func(t *testing.T, query string) {
+		values, err := url.ParseQuery(query)
+		if err != nil {
+			return
+		}
+		root, err := NewParser().ParseOData(values)
+		if err != nil {
+			return
+		}
+		_, _ = NewSqlTranslator(root).Sql()
+		_, _ = FormatOData(root)
+	})
+}
+
+func FuzzParseAIP(f *testing.F) {
+	for _, test := range aipTests {
+		f.Add(test.AIP)
+	}
+	f.Fuzz(func(t *testing.T, filter string) {
+		root, err := NewParser().ParseAIP(filter)
+		if err != nil {
+			return
+		}
+		_, _ = NewSqlTranslator(root).Sql()
+		_, _ = FormatAIP(root.Node)
+	})
+}
+
+func FuzzParseMongo(f *testing.F) {
+	for _, test := range mongoTests {
+		f.Add(test.Mongo)
+	}
+	f.Fuzz(func(t *testing.T, mongo string) {
+		root, err := NewParser().ParseMongo([]byte(mongo))
+		if err != nil {
+			return
+		}
+		_, _ = NewSqlTranslator(root).Sql()
+	})
+}
+
+func FuzzParseCEL(f *testing.F) {
+	for _, test := range celTests {
+		f.Add(test.CEL)
+	}
+	f.Fuzz(func(t *testing.T, expr string) {
+		root, err := NewParser().ParseCEL(expr)
+		if err != nil {
+			return
+		}
+		_, _ = NewSqlTranslator(root).Sql()
+		_, _ = FormatCEL(root.Node)
+	})
+}
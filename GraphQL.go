@@ -0,0 +1,376 @@
+package rqlParser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// GraphQLDialect is the flavor of the GraphQL where input objects
+type GraphQLDialect int
+
+const (
+	HasuraDialect GraphQLDialect = iota // {_and:[{price:{_gt:10}}]}, order_by, limit and offset
+	PrismaDialect                       // {AND:[{price:{gt:10}}]}, orderBy, take and skip
+)
+
+// GraphQLOpFunc returns the where input object of an operator
+type GraphQLOpFunc func(*RqlNode) (map[string]interface{}, error)
+
+// graphQLNames holds the names of a dialect
+type graphQLNames struct {
+	and, or, not           string
+	ops                    map[string]string // comparison operators
+	orderBy, limit, offset string            // arguments of the root field
+}
+
+var graphQLDialects = map[GraphQLDialect]graphQLNames{
+	HasuraDialect: {
+		and: "_and", or: "_or", not: "_not",
+		ops: map[string]string{
+			"eq": "_eq", "ne": "_neq", "gt": "_gt", "ge": "_gte", "lt": "_lt", "le": "_lte",
+			"in": "_in", "out": "_nin", "like": "_like", "match": "_ilike",
+		},
+		orderBy: "order_by", limit: "limit", offset: "offset",
+	},
+	PrismaDialect: {
+		and: "AND", or: "OR", not: "NOT",
+		ops: map[string]string{
+			"eq": "equals", "ne": "not", "gt": "gt", "ge": "gte", "lt": "lt", "le": "lte",
+			"in": "in", "out": "notIn",
+		},
+		orderBy: "orderBy", limit: "take", offset: "skip",
+	},
+}
+
+// graphQLName matches the GraphQL names
+var graphQLName = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+
+// hasuraLikeEscaper escapes the SQL wildcards of the like patterns
+var hasuraLikeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+type GraphQLTranslator struct {
+	rootNode *RqlRootNode
+	dialect  GraphQLDialect
+	names    graphQLNames
+	opsDic   map[string]GraphQLOpFunc
+	opSpecs  *OpSpecs
+}
+
+// NewGraphQLTranslator returns a translator for the dialect, Where, OrderBy
+// and Args return an error when the dialect is unknown
+func NewGraphQLTranslator(r *RqlRootNode, dialect GraphQLDialect) (gt *GraphQLTranslator) {
+	gt = &GraphQLTranslator{rootNode: r, dialect: dialect, names: graphQLDialects[dialect], opsDic: map[string]GraphQLOpFunc{}, opSpecs: NewOpSpecs()}
+
+	gt.SetOpFunc("AND", gt.GetAndOrOpFunc(gt.names.and))
+	gt.SetOpFunc("OR", gt.GetAndOrOpFunc(gt.names.or))
+	gt.SetOpFunc("NOT", gt.GetNotOpFunc())
+
+	gt.SetOpFunc("EQ", gt.GetEqualityOpFunc(false))
+	gt.SetOpFunc("NE", gt.GetEqualityOpFunc(true))
+	gt.SetOpFunc("GT", gt.GetComparisonOpFunc(gt.names.ops["gt"]))
+	gt.SetOpFunc("GE", gt.GetComparisonOpFunc(gt.names.ops["ge"]))
+	gt.SetOpFunc("LT", gt.GetComparisonOpFunc(gt.names.ops["lt"]))
+	gt.SetOpFunc("LE", gt.GetComparisonOpFunc(gt.names.ops["le"]))
+	gt.SetOpFunc("IN", gt.GetInOpFunc(gt.names.ops["in"]))
+	gt.SetOpFunc("OUT", gt.GetInOpFunc(gt.names.ops["out"]))
+
+	if dialect == PrismaDialect {
+		gt.SetOpFunc("LIKE", gt.GetPrismaLikeOpFunc(false))
+		gt.SetOpFunc("MATCH", gt.GetPrismaLikeOpFunc(true))
+	} else {
+		gt.SetOpFunc("LIKE", gt.GetHasuraLikeOpFunc(gt.names.ops["like"]))
+		gt.SetOpFunc("MATCH", gt.GetHasuraLikeOpFunc(gt.names.ops["match"]))
+	}
+
+	return
+}
+
+func (gt *GraphQLTranslator) SetOpFunc(op string, f GraphQLOpFunc) {
+	gt.opsDic[strings.ToUpper(op)] = f
+}
+
+// DeleteOpFunc removes an operator, its OpSpec is removed too
+func (gt *GraphQLTranslator) DeleteOpFunc(op string) {
+	delete(gt.opsDic, strings.ToUpper(op))
+	gt.opSpecs.Delete(op)
+}
+
+// SetOpSpecs sets the registry used to validate the operators arguments, nil
+// disables the validation. The registry is copied so DeleteOpFunc doesn't
+// change the given one (eg: the registry of the Parser).
+func (gt *GraphQLTranslator) SetOpSpecs(specs *OpSpecs) {
+	gt.opSpecs = specs.Clone()
+}
+
+// checkDialect returns an error when the dialect of the translator is unknown
+func (gt *GraphQLTranslator) checkDialect() error {
+	if _, ok := graphQLDialects[gt.dialect]; !ok {
+		return fmt.Errorf("Unknown GraphQL dialect : %d", gt.dialect)
+	}
+	return nil
+}
+
+// Where returns the where input object, empty when the query is empty
+func (gt *GraphQLTranslator) Where() (map[string]interface{}, error) {
+	if err := gt.checkDialect(); err != nil {
+		return nil, err
+	}
+	if gt.rootNode == nil || gt.rootNode.Node == nil {
+		return map[string]interface{}{}, nil
+	}
+	if err := gt.opSpecs.Validate(gt.rootNode.Node); err != nil {
+		return nil, err
+	}
+	return gt.where(gt.rootNode.Node)
+}
+
+func (gt *GraphQLTranslator) where(n *RqlNode) (map[string]interface{}, error) {
+	f := gt.opsDic[strings.ToUpper(n.Op)]
+	if f == nil {
+		return nil, fmt.Errorf("No GraphQLOpFunc for op : '%s'", n.Op)
+	}
+	return f(n)
+}
+
+// OrderBy returns the order_by (orderBy) argument (eg: [{price:desc},{user:{name:asc}}])
+func (gt *GraphQLTranslator) OrderBy() ([]interface{}, error) {
+	if err := gt.checkDialect(); err != nil {
+		return nil, err
+	}
+	if gt.rootNode == nil {
+		return nil, nil
+	}
+
+	var orderBy []interface{}
+	for _, s := range gt.rootNode.Sort() {
+		direction := "asc"
+		if s.Desc() {
+			direction = "desc"
+		}
+		o, err := graphQLField(s.By(), direction)
+		if err != nil {
+			return nil, err
+		}
+		orderBy = append(orderBy, o)
+	}
+	return orderBy, nil
+}
+
+// Args returns the where, order_by, limit and offset arguments (where, orderBy,
+// take and skip with Prisma), the empty ones are omitted
+func (gt *GraphQLTranslator) Args() (map[string]interface{}, error) {
+	args := map[string]interface{}{}
+
+	where, err := gt.Where()
+	if err != nil {
+		return nil, err
+	}
+	if len(where) > 0 {
+		args["where"] = where
+	}
+
+	orderBy, err := gt.OrderBy()
+	if err != nil {
+		return nil, err
+	}
+	if len(orderBy) > 0 {
+		args[gt.names.orderBy] = orderBy
+	}
+
+	if gt.rootNode != nil {
+		if gt.rootNode.Limit() != "" && gt.rootNode.LimitInt() != InfiniteLimit {
+			args[gt.names.limit] = gt.rootNode.LimitInt()
+		}
+		if gt.rootNode.Offset() != "" {
+			args[gt.names.offset] = gt.rootNode.OffsetInt()
+		}
+	}
+
+	return args, nil
+}
+
+// graphQLField nests the value in the objects of the dotted field (eg: user.name
+// is {user:{name:value}})
+func graphQLField(arg interface{}, value interface{}) (map[string]interface{}, error) {
+	field, ok := arg.(string)
+	if !ok {
+		return nil, fmt.Errorf("Invalid field name : %v", arg)
+	}
+
+	segments := strings.Split(field, ".")
+	for i := len(segments) - 1; i >= 0; i-- {
+		if !graphQLName.MatchString(segments[i]) {
+			return nil, fmt.Errorf("Invalid field name : %v", arg)
+		}
+		value = map[string]interface{}{segments[i]: value}
+	}
+	return value.(map[string]interface{}), nil
+}
+
+// graphQLFieldValue returns the field and the value of a comparison
+func graphQLFieldValue(n *RqlNode) (string, interface{}, error) {
+	if len(n.Args) != 2 {
+		return "", nil, &ArgumentError{Op: n.Op, Reason: "requires 2 arguments"}
+	}
+	field, ok := n.Args[0].(string)
+	if !ok {
+		return "", nil, fmt.Errorf("Invalid field name : %v", n.Args[0])
+	}
+	switch n.Args[1].(type) {
+	case string, StringLiteral:
+	default:
+		return "", nil, &ArgumentError{Op: n.Op, Arg: n.Args[1], Reason: "must be a value"}
+	}
+	return field, n.Args[1], nil
+}
+
+func (gt *GraphQLTranslator) GetAndOrOpFunc(op string) GraphQLOpFunc {
+	return GraphQLOpFunc(func(n *RqlNode) (map[string]interface{}, error) {
+		if len(n.Args) == 0 {
+			return nil, &ArgumentError{Op: n.Op, Reason: "requires at least 1 argument"}
+		}
+
+		objects := make([]interface{}, len(n.Args))
+		for i, a := range n.Args {
+			c, ok := a.(*RqlNode)
+			if !ok {
+				return nil, &ArgumentError{Op: n.Op, Arg: a, Reason: "must be an operator"}
+			}
+			o, err := gt.where(c)
+			if err != nil {
+				return nil, err
+			}
+			objects[i] = o
+		}
+
+		return map[string]interface{}{op: objects}, nil
+	})
+}
+
+func (gt *GraphQLTranslator) GetNotOpFunc() GraphQLOpFunc {
+	return GraphQLOpFunc(func(n *RqlNode) (map[string]interface{}, error) {
+		if len(n.Args) != 1 {
+			return nil, &ArgumentError{Op: n.Op, Reason: "requires 1 argument"}
+		}
+		c, ok := n.Args[0].(*RqlNode)
+		if !ok {
+			return nil, &ArgumentError{Op: n.Op, Arg: n.Args[0], Reason: "must be an operator"}
+		}
+		o, err := gt.where(c)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{gt.names.not: o}, nil
+	})
+}
+
+// GetEqualityOpFunc returns an (in)equality, the comparison to null is
+// _is_null with Hasura and an (in)equality to null with Prisma
+func (gt *GraphQLTranslator) GetEqualityOpFunc(negated bool) GraphQLOpFunc {
+	op := gt.names.ops["eq"]
+	if negated {
+		op = gt.names.ops["ne"]
+	}
+
+	return GraphQLOpFunc(func(n *RqlNode) (map[string]interface{}, error) {
+		field, value, err := graphQLFieldValue(n)
+		if err != nil {
+			return nil, err
+		}
+
+		v := nativeValue(value)
+		if v == nil && gt.dialect == HasuraDialect {
+			return graphQLField(field, map[string]interface{}{"_is_null": !negated})
+		}
+		return graphQLField(field, map[string]interface{}{op: v})
+	})
+}
+
+func (gt *GraphQLTranslator) GetComparisonOpFunc(op string) GraphQLOpFunc {
+	return GraphQLOpFunc(func(n *RqlNode) (map[string]interface{}, error) {
+		field, value, err := graphQLFieldValue(n)
+		if err != nil {
+			return nil, err
+		}
+
+		v := nativeValue(value)
+		if v == nil {
+			return nil, &ArgumentError{Op: n.Op, Arg: value, Reason: "can't be compared"}
+		}
+		return graphQLField(field, map[string]interface{}{op: v})
+	})
+}
+
+func (gt *GraphQLTranslator) GetInOpFunc(op string) GraphQLOpFunc {
+	return GraphQLOpFunc(func(n *RqlNode) (map[string]interface{}, error) {
+		if len(n.Args) < 2 {
+			return nil, &ArgumentError{Op: n.Op, Reason: "requires at least 2 arguments"}
+		}
+
+		values := make([]interface{}, len(n.Args)-1)
+		for i, a := range n.Args[1:] {
+			switch a.(type) {
+			case string, StringLiteral:
+				values[i] = nativeValue(a)
+			default:
+				return nil, &ArgumentError{Op: n.Op, Arg: a, Reason: "must be a value"}
+			}
+		}
+
+		return graphQLField(n.Args[0], map[string]interface{}{op: values})
+	})
+}
+
+// GetHasuraLikeOpFunc returns a SQL pattern (_like or _ilike), the "*" are
+// translated to "%" and the SQL wildcards of the value are escaped
+func (gt *GraphQLTranslator) GetHasuraLikeOpFunc(op string) GraphQLOpFunc {
+	return GraphQLOpFunc(func(n *RqlNode) (map[string]interface{}, error) {
+		field, value, err := graphQLFieldValue(n)
+		if err != nil {
+			return nil, err
+		}
+
+		parts := strings.Split(fmt.Sprint(value), "*")
+		for i, p := range parts {
+			parts[i] = hasuraLikeEscaper.Replace(p)
+		}
+		return graphQLField(field, map[string]interface{}{op: strings.Join(parts, "%")})
+	})
+}
+
+// GetPrismaLikeOpFunc returns startsWith (abc*), endsWith (*abc), contains
+// (*abc*) or equals (abc), the other patterns have no Prisma equivalent. When
+// ignoreCase is true, the mode is insensitive.
+func (gt *GraphQLTranslator) GetPrismaLikeOpFunc(ignoreCase bool) GraphQLOpFunc {
+	return GraphQLOpFunc(func(n *RqlNode) (map[string]interface{}, error) {
+		field, value, err := graphQLFieldValue(n)
+		if err != nil {
+			return nil, err
+		}
+
+		pattern := fmt.Sprint(value)
+		inner := strings.TrimSuffix(strings.TrimPrefix(pattern, "*"), "*")
+		if strings.Contains(inner, "*") {
+			return nil, &ArgumentError{Op: n.Op, Arg: value, Reason: "has no Prisma equivalent"}
+		}
+
+		var op string
+		switch {
+		case len(pattern) > len(inner)+1:
+			op = "contains"
+		case strings.HasSuffix(pattern, "*"):
+			op = "startsWith"
+		case strings.HasPrefix(pattern, "*"):
+			op = "endsWith"
+		default:
+			op = "equals"
+		}
+
+		filter := map[string]interface{}{op: inner}
+		if ignoreCase {
+			filter["mode"] = "insensitive"
+		}
+		return graphQLField(field, filter)
+	})
+}
@@ -0,0 +1,155 @@
+package rqlParser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type gqlObj = map[string]interface{}
+
+type GraphQLTest struct {
+	Name                string         // Name of the test
+	RQL                 string         // Input RQL query
+	Dialect             GraphQLDialect // Dialect of the where input objects
+	Args                gqlObj         // Expected arguments
+	WantTranslatorError bool           // Test should raise an error when translating
+}
+
+func (test *GraphQLTest) Run(t *testing.T) {
+	root, err := NewParser().Parse(strings.NewReader(test.RQL))
+	if err != nil {
+		t.Fatalf("(%s) Unexpected parse error : %v", test.Name, err)
+	}
+
+	args, err := NewGraphQLTranslator(root, test.Dialect).Args()
+	if test.WantTranslatorError != (err != nil) {
+		t.Fatalf("(%s) Expecting error :%v\nGot error : %v", test.Name, test.WantTranslatorError, err)
+	}
+	if err != nil {
+		return
+	}
+
+	if !reflect.DeepEqual(args, test.Args) {
+		t.Fatalf("(%s) Translated arguments don’t match the expected ones %v vs %v", test.Name, args, test.Args)
+	}
+}
+
+var graphQLTests = []GraphQLTest{
+	{
+		Name:    `Hasura comparisons, order_by, limit and offset`,
+		RQL:     `gt(price,10)&eq(status,open)&sort(-price,+user.name)&limit(20,40)`,
+		Dialect: HasuraDialect,
+		Args: gqlObj{
+			"where":    gqlObj{"_and": []interface{}{gqlObj{"price": gqlObj{"_gt": int64(10)}}, gqlObj{"status": gqlObj{"_eq": "open"}}}},
+			"order_by": []interface{}{gqlObj{"price": "desc"}, gqlObj{"user": gqlObj{"name": "asc"}}},
+			"limit":    20,
+			"offset":   40,
+		},
+	},
+	{
+		Name:    `Prisma comparisons, orderBy, take and skip`,
+		RQL:     `gt(price,10)&eq(status,open)&sort(-price,+user.name)&limit(20,40)`,
+		Dialect: PrismaDialect,
+		Args: gqlObj{
+			"where":   gqlObj{"AND": []interface{}{gqlObj{"price": gqlObj{"gt": int64(10)}}, gqlObj{"status": gqlObj{"equals": "open"}}}},
+			"orderBy": []interface{}{gqlObj{"price": "desc"}, gqlObj{"user": gqlObj{"name": "asc"}}},
+			"take":    20,
+			"skip":    40,
+		},
+	},
+	{
+		Name:    `Hasura logical operators, null and lists`,
+		RQL:     `or(not(le(a,1.5)),eq(b,null),ne(c,null),in(d,x,"2"),out(e.f,true))`,
+		Dialect: HasuraDialect,
+		Args: gqlObj{"where": gqlObj{"_or": []interface{}{
+			gqlObj{"_not": gqlObj{"a": gqlObj{"_lte": 1.5}}},
+			gqlObj{"b": gqlObj{"_is_null": true}},
+			gqlObj{"c": gqlObj{"_is_null": false}},
+			gqlObj{"d": gqlObj{"_in": []interface{}{"x", "2"}}},
+			gqlObj{"e": gqlObj{"f": gqlObj{"_nin": []interface{}{true}}}},
+		}}},
+	},
+	{
+		Name:    `Prisma logical operators, null and lists`,
+		RQL:     `or(not(le(a,1.5)),eq(b,null),ne(c,null),in(d,x,"2"),out(e.f,true))`,
+		Dialect: PrismaDialect,
+		Args: gqlObj{"where": gqlObj{"OR": []interface{}{
+			gqlObj{"NOT": gqlObj{"a": gqlObj{"lte": 1.5}}},
+			gqlObj{"b": gqlObj{"equals": nil}},
+			gqlObj{"c": gqlObj{"not": nil}},
+			gqlObj{"d": gqlObj{"in": []interface{}{"x", "2"}}},
+			gqlObj{"e": gqlObj{"f": gqlObj{"notIn": []interface{}{true}}}},
+		}}},
+	},
+	{
+		Name:    `Hasura like and match`,
+		RQL:     `like(a,"Chris*")&like(b,"50%*_x")&match(c,"*ris*")&limit(Infinity)`,
+		Dialect: HasuraDialect,
+		Args: gqlObj{"where": gqlObj{"_and": []interface{}{
+			gqlObj{"a": gqlObj{"_like": "Chris%"}},
+			gqlObj{"b": gqlObj{"_like": `50\%%\_x`}},
+			gqlObj{"c": gqlObj{"_ilike": "%ris%"}},
+		}}},
+	},
+	{
+		Name:    `Prisma like and match`,
+		RQL:     `like(a,"Chris*")&like(b,"*son")&like(c,"x")&match(d,"*ris*")`,
+		Dialect: PrismaDialect,
+		Args: gqlObj{"where": gqlObj{"AND": []interface{}{
+			gqlObj{"a": gqlObj{"startsWith": "Chris"}},
+			gqlObj{"b": gqlObj{"endsWith": "son"}},
+			gqlObj{"c": gqlObj{"equals": "x"}},
+			gqlObj{"d": gqlObj{"contains": "ris", "mode": "insensitive"}},
+		}}},
+	},
+	{
+		Name:    `Values with leading zeros`,
+		RQL:     `eq(zip,01234)&in(code,007,7,0.5)`,
+		Dialect: HasuraDialect,
+		Args: gqlObj{"where": gqlObj{"_and": []interface{}{
+			gqlObj{"zip": gqlObj{"_eq": "01234"}},
+			gqlObj{"code": gqlObj{"_in": []interface{}{"007", int64(7), 0.5}}},
+		}}},
+	},
+	{
+		Name:    `Empty query`,
+		RQL:     ``,
+		Dialect: PrismaDialect,
+		Args:    gqlObj{},
+	},
+	{
+		Name:                `Prisma like without equivalent`,
+		RQL:                 `like(a,"C*s")`,
+		Dialect:             PrismaDialect,
+		WantTranslatorError: true,
+	},
+	{
+		Name:                `Comparison to null`,
+		RQL:                 `gt(a,null)`,
+		Dialect:             HasuraDialect,
+		WantTranslatorError: true,
+	},
+	{
+		Name:                `Invalid field`,
+		RQL:                 `eq(my-field,1)`,
+		Dialect:             HasuraDialect,
+		WantTranslatorError: true,
+	},
+}
+
+func TestGraphQLTranslator(t *testing.T) {
+	for _, test := range graphQLTests {
+		test.Run(t)
+	}
+}
+
+func TestGraphQLUnknownDialect(t *testing.T) {
+	gt := NewGraphQLTranslator(&RqlRootNode{}, GraphQLDialect(-1))
+	if _, err := gt.Args(); err == nil {
+		t.Fatalf("Expecting an error for an unknown dialect")
+	}
+	if _, err := gt.OrderBy(); err == nil {
+		t.Fatalf("Expecting an error for an unknown dialect")
+	}
+}
@@ -0,0 +1,261 @@
+package rqlParser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// infixComparisonOps maps the infix comparison operators to the RQL operators
+var infixComparisonOps = map[Token]string{
+	EQUAL_SIGN:       "eq",
+	EQUAL:            "eq",
+	NOT_EQUAL:        "ne",
+	LOWER:            "lt",
+	LOWER_OR_EQUAL:   "le",
+	GREATER:          "gt",
+	GREATER_OR_EQUAL: "ge",
+}
+
+// parseInfixQuery parses an infix query and sets the node, the sort and the
+// limit of the root. The grammar is :
+//
+//	query      = [ or ] [ "order" "by" key { "," key } ] [ "limit" IDENT [ "offset" IDENT ] ] EOF
+//	or         = and { "or" and }
+//	and        = unary { "and" unary }
+//	unary      = "not" unary | "(" or ")" | comparison
+//	comparison = IDENT comparator value | IDENT [ "not" ] "like" value | IDENT [ "not" ] "in" "(" value { "," value } ")"
+//	comparator = "=" | "==" | "!=" | "<" | "<=" | ">" | ">="
+//	key        = IDENT [ "asc" | "desc" ]
+//	value      = IDENT | STRING
+//
+// The keywords are case insensitive. A keyword followed by a comparator is a field name (eg: order = 5).
+func (p *parser) parseInfixQuery(root *RqlRootNode) (err error) {
+	if err = p.next(); err != nil {
+		return err
+	}
+
+	isOrder, err := p.isInfixKeyword("order")
+	if err != nil {
+		return err
+	}
+	isLimit, err := p.isInfixKeyword("limit")
+	if err != nil {
+		return err
+	}
+	if p.tok.t != EOF && !isOrder && !isLimit {
+		if root.Node, err = p.parseInfixOr(); err != nil {
+			return err
+		}
+	}
+
+	if p.isRSQLKeyword("order") {
+		if err = p.parseInfixOrderBy(root); err != nil {
+			return err
+		}
+	}
+	if p.isRSQLKeyword("limit") {
+		if err = p.parseInfixLimit(root); err != nil {
+			return err
+		}
+	}
+
+	if p.tok.t != EOF {
+		return p.unexpected()
+	}
+	return nil
+}
+
+// isInfixKeyword reports whether the current token is the keyword and not a field name
+func (p *parser) isInfixKeyword(keyword string) (bool, error) {
+	if !p.isRSQLKeyword(keyword) {
+		return false, nil
+	}
+	next, err := p.peek()
+	if err != nil {
+		return false, err
+	}
+	_, isComparator := infixComparisonOps[next.t]
+	return !isComparator, nil
+}
+
+func (p *parser) parseInfixOr() (*RqlNode, error) {
+	return p.parseRSQLList("OR", func() bool {
+		return p.isRSQLKeyword("or")
+	}, p.parseInfixAnd)
+}
+
+func (p *parser) parseInfixAnd() (*RqlNode, error) {
+	return p.parseRSQLList("AND", func() bool {
+		return p.isRSQLKeyword("and")
+	}, p.parseInfixUnary)
+}
+
+func (p *parser) parseInfixUnary() (*RqlNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	isNot, err := p.isInfixKeyword("not")
+	if err != nil {
+		return nil, err
+	}
+	if isNot {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		n, err := p.parseInfixUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &RqlNode{Op: "not", Args: []interface{}{n}}, nil
+	}
+
+	if p.tok.t == OPENING_PARENTHESIS {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		n, err := p.parseInfixOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.t != CLOSING_PARENTHESIS {
+			return nil, fmt.Errorf("Missing closing parenthesis")
+		}
+		return n, p.next()
+	}
+
+	return p.parseInfixComparison()
+}
+
+func (p *parser) parseInfixComparison() (*RqlNode, error) {
+	if p.tok.t != IDENT {
+		return nil, p.unexpected()
+	}
+	field := p.tok.s
+
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	if op, ok := infixComparisonOps[p.tok.t]; ok {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		value, err := p.parseRSQLValue()
+		if err != nil {
+			return nil, err
+		}
+		return &RqlNode{Op: op, Args: []interface{}{field, value}}, nil
+	}
+
+	negated := p.isRSQLKeyword("not")
+	if negated {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+
+	var n *RqlNode
+	switch {
+	case p.isRSQLKeyword("like"):
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		value, err := p.parseRSQLValue()
+		if err != nil {
+			return nil, err
+		}
+		n = &RqlNode{Op: "like", Args: []interface{}{field, value}}
+		if negated {
+			n = &RqlNode{Op: "not", Args: []interface{}{n}}
+		}
+	case p.isRSQLKeyword("in"):
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if p.tok.t != OPENING_PARENTHESIS {
+			return nil, p.unexpected()
+		}
+		values, err := p.parseRSQLArguments()
+		if err != nil {
+			return nil, err
+		}
+		n = &RqlNode{Op: "in", Args: append([]interface{}{field}, values...)}
+		if negated {
+			n.Op = "out"
+		}
+	default:
+		return nil, fmt.Errorf("Missing comparison operator after %s", field)
+	}
+
+	return n, nil
+}
+
+// parseInfixOrderBy reads the sort keys (eg: order by -date, name asc)
+func (p *parser) parseInfixOrderBy(root *RqlRootNode) error {
+	if err := p.next(); err != nil {
+		return err
+	}
+	if !p.isRSQLKeyword("by") {
+		return fmt.Errorf("Missing 'by' after order")
+	}
+
+	sort := &RqlNode{Op: "sort"}
+	for {
+		if err := p.next(); err != nil {
+			return err
+		}
+		if p.tok.t != IDENT {
+			return p.unexpected()
+		}
+		key := p.tok.s
+		if err := p.next(); err != nil {
+			return err
+		}
+
+		if direction := strings.ToLower(p.tok.s); p.tok.t == IDENT && (direction == "asc" || direction == "desc") {
+			if strings.HasPrefix(key, "+") || strings.HasPrefix(key, "-") {
+				return &ArgumentError{Op: sort.Op, Arg: key + " " + p.tok.s, Reason: "must have a single direction"}
+			}
+			if direction == "desc" {
+				key = "-" + key
+			}
+			if err := p.next(); err != nil {
+				return err
+			}
+		}
+		sort.Args = append(sort.Args, key)
+
+		if p.tok.t != COMMA {
+			break
+		}
+	}
+
+	_, err := parseSort(sort, root)
+	return err
+}
+
+// parseInfixLimit reads the limit and the optional offset (eg: limit 20 offset 40)
+func (p *parser) parseInfixLimit(root *RqlRootNode) error {
+	limit := &RqlNode{Op: "limit"}
+
+	for _, keyword := range []string{"limit", "offset"} {
+		if !p.isRSQLKeyword(keyword) {
+			break
+		}
+		if err := p.next(); err != nil {
+			return err
+		}
+		if p.tok.t != IDENT {
+			return p.unexpected()
+		}
+		limit.Args = append(limit.Args, p.tok.s)
+		if err := p.next(); err != nil {
+			return err
+		}
+	}
+
+	_, err := parseLimit(limit, root)
+	return err
+}
@@ -0,0 +1,126 @@
+package rqlParser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type InfixTest struct {
+	Name           string // Name of the test
+	Infix          string // Input infix query
+	RQL            string // Equivalent RQL query producing the same root node
+	SQL            string // Expected Output SQL
+	WantParseError bool   // Test should raise an error when parsing the infix query
+}
+
+func (test *InfixTest) Run(t *testing.T) {
+	p := NewParser()
+	p.SetSyntax(InfixSyntax)
+
+	root, err := p.Parse(strings.NewReader(test.Infix))
+	if test.WantParseError != (err != nil) {
+		t.Fatalf("(%s) Expecting error :%v\nGot error : %v", test.Name, test.WantParseError, err)
+	}
+	if err != nil {
+		return
+	}
+
+	if test.RQL != "" {
+		rqlRoot, err := NewParser().Parse(strings.NewReader(test.RQL))
+		if err != nil {
+			t.Fatalf("(%s) Unexpected RQL parse error : %v", test.Name, err)
+		}
+		if !reflect.DeepEqual(root, rqlRoot) {
+			t.Fatalf("(%s) Infix root doesn’t match the RQL one %+v vs %+v", test.Name, root, rqlRoot)
+		}
+	}
+
+	s, err := NewSqlTranslator(root).Sql()
+	if err != nil {
+		t.Fatalf("(%s) Unexpected translator error : %v", test.Name, err)
+	}
+	if s != test.SQL {
+		t.Fatalf("(%s) Translated SQL doesn’t match the expected one %s vs %s", test.Name, s, test.SQL)
+	}
+}
+
+var infixTests = []InfixTest{
+	{
+		Name:  `Search bar query`,
+		Infix: `price > 10 and (status = "open" or owner = me) order by -date limit 20`,
+		RQL:   `gt(price,10)&(eq(status,"open")|eq(owner,me))&sort(-date)&limit(20)`,
+		SQL:   `WHERE ((price > 10) AND ((status = 'open') OR (owner = 'me'))) ORDER BY date DESC LIMIT 20`,
+	},
+	{
+		Name:  `Precedence of and over or`,
+		Infix: `a = 1 or b == 2 and c != 3`,
+		RQL:   `OR(eq(a,1),AND(eq(b,2),ne(c,3)))`,
+		SQL:   `WHERE ((a = 1) OR ((b = 2) AND (c != 3)))`,
+	},
+	{
+		Name:  `Comparison operators and case insensitive keywords`,
+		Infix: `a < 1 AND b <= 2 And c >= 3`,
+		RQL:   `AND(lt(a,1),le(b,2),ge(c,3))`,
+		SQL:   `WHERE ((a < 1) AND (b <= 2) AND (c >= 3))`,
+	},
+	{
+		Name:  `Not, like and in`,
+		Infix: `not (a = 1) and name like Chris* and title not like "*draft*" and c in (1, 2) and d not in (x)`,
+		RQL:   `AND(not(eq(a,1)),like(name,Chris*),not(like(title,"*draft*")),in(c,1,2),out(d,x))`,
+		SQL:   `WHERE (NOT((a = 1)) AND (name LIKE 'Chris%') AND NOT((title LIKE '%draft%')) AND (c IN (1, 2)) AND (d NOT IN ('x')))`,
+	},
+	{
+		Name:  `Order by with directions, limit and offset`,
+		Infix: `order by name asc, date DESC, +id limit 10 offset 20`,
+		RQL:   `sort(+name,-date,+id)&limit(10,20)`,
+		SQL:   ` ORDER BY name, date DESC, id LIMIT 10 OFFSET 20`,
+	},
+	{
+		Name:  `Keywords as field names`,
+		Infix: `order = 1 and limit >= 2 and not != 3`,
+		RQL:   `AND(eq(order,1),ge(limit,2),ne(not,3))`,
+		SQL:   `WHERE ((order = 1) AND (limit >= 2) AND (not != 3))`,
+	},
+	{
+		Name:  `Empty query`,
+		Infix: ``,
+		SQL:   ``,
+	},
+	{
+		Name:           `Missing comparison operator`,
+		Infix:          `price and a = 1`,
+		WantParseError: true,
+	},
+	{
+		Name:           `Missing closing parenthesis`,
+		Infix:          `(a = 1 or b = 2`,
+		WantParseError: true,
+	},
+	{
+		Name:           `Missing by`,
+		Infix:          `a = 1 order date`,
+		WantParseError: true,
+	},
+	{
+		Name:           `Conflicting sort directions`,
+		Infix:          `order by -date asc`,
+		WantParseError: true,
+	},
+	{
+		Name:           `Invalid limit`,
+		Infix:          `a = 1 limit -1`,
+		WantParseError: true,
+	},
+	{
+		Name:           `Limit before order by`,
+		Infix:          `limit 10 order by date`,
+		WantParseError: true,
+	},
+}
+
+func TestInfix(t *testing.T) {
+	for _, test := range infixTests {
+		test.Run(t)
+	}
+}
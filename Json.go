@@ -0,0 +1,251 @@
+package rqlParser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// jsonNode is the JSON form of a RqlNode : {"name":"eq","args":["price",10]}
+type jsonNode struct {
+	Name string            `json:"name"`
+	Args []json.RawMessage `json:"args"`
+}
+
+// jsonLiteral is the JSON form of a StringLiteral which would be read as an
+// unquoted value from a JSON string : {"literal":"foo bar"}
+type jsonLiteral struct {
+	Literal *string `json:"literal"`
+}
+
+// jsonRootNode is the JSON form of a RqlRootNode
+type jsonRootNode struct {
+	Query  *RqlNode        `json:"query,omitempty"`
+	Sort   []string        `json:"sort,omitempty"`
+	Limit  json.RawMessage `json:"limit,omitempty"` // Number or "Infinity"
+	Offset *int            `json:"offset,omitempty"`
+	Select []string        `json:"select,omitempty"`
+	After  string          `json:"after,omitempty"`
+	Before string          `json:"before,omitempty"`
+}
+
+// MarshalJSON returns the node as {"name":"op","args":[...]}. The unquoted
+// values which are JSON numbers, booleans or null are written with their JSON
+// type and the other values as JSON strings. The quoted values are written as
+// JSON strings when they look like a number, a boolean or null (eg: "42") and
+// as {"literal":"..."} otherwise.
+func (n RqlNode) MarshalJSON() ([]byte, error) {
+	args := make([]interface{}, len(n.Args))
+	for i, a := range n.Args {
+		switch v := a.(type) {
+		case *RqlNode:
+			args[i] = v
+		case string:
+			args[i] = jsonValue(v)
+		case StringLiteral:
+			if _, typed := jsonValue(string(v)).(json.RawMessage); typed {
+				args[i] = string(v)
+			} else {
+				literal := string(v)
+				args[i] = jsonLiteral{Literal: &literal}
+			}
+		default:
+			return nil, &ArgumentError{Op: n.Op, Arg: a, Reason: "unsupported argument type"}
+		}
+	}
+	return json.Marshal(struct {
+		Name string        `json:"name"`
+		Args []interface{} `json:"args"`
+	}{n.Op, args})
+}
+
+// UnmarshalJSON reads a node written by MarshalJSON. The JSON numbers,
+// booleans and null are read as unquoted values. The JSON strings which would
+// be read as a number, a boolean or null and the {"literal":"..."} objects are
+// read as StringLiteral, the other JSON strings as unquoted values.
+func (n *RqlNode) UnmarshalJSON(data []byte) error {
+	var jn jsonNode
+	if err := json.Unmarshal(data, &jn); err != nil {
+		return err
+	}
+	if jn.Name == "" {
+		return fmt.Errorf("Missing operator name in %s", data)
+	}
+
+	n.Op, n.Args = jn.Name, make([]interface{}, len(jn.Args))
+	for i, raw := range jn.Args {
+		raw = bytes.TrimSpace(raw)
+		if len(raw) > 0 && raw[0] == '{' {
+			var l jsonLiteral
+			if err := json.Unmarshal(raw, &l); err != nil {
+				return err
+			}
+			if l.Literal != nil {
+				n.Args[i] = StringLiteral(*l.Literal)
+				continue
+			}
+
+			c := &RqlNode{}
+			if err := json.Unmarshal(raw, c); err != nil {
+				return err
+			}
+			n.Args[i] = c
+			continue
+		}
+
+		var v interface{}
+		d := json.NewDecoder(bytes.NewReader(raw))
+		d.UseNumber()
+		if err := d.Decode(&v); err != nil {
+			return err
+		}
+		switch value := v.(type) {
+		case nil:
+			n.Args[i] = "null"
+		case bool:
+			n.Args[i] = strconv.FormatBool(value)
+		case json.Number:
+			n.Args[i] = value.String()
+		case string:
+			if _, typed := jsonValue(value).(json.RawMessage); typed {
+				n.Args[i] = StringLiteral(value)
+			} else {
+				n.Args[i] = value
+			}
+		default:
+			return &ArgumentError{Op: n.Op, Arg: string(raw), Reason: "unsupported argument type"}
+		}
+	}
+
+	return nil
+}
+
+// jsonValue returns the JSON literal of an unquoted value which is a JSON
+// number, a boolean or null, the value itself otherwise
+func jsonValue(s string) interface{} {
+	switch s {
+	case "null", "true", "false":
+		return json.RawMessage(s)
+	}
+	if jsonNumber.MatchString(s) {
+		return json.RawMessage(s)
+	}
+	return s
+}
+
+// jsonNumber matches the exact JSON numbers, without surrounding whitespaces
+var jsonNumber = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// MarshalJSON returns the root node as {"query":{...},"sort":["-date"],"limit":20,"offset":40}.
+// The limit is "Infinity" for limit(Infinity) and the empty parts are omitted.
+func (r RqlRootNode) MarshalJSON() ([]byte, error) {
+	jr := jsonRootNode{Query: r.Node, Select: r.selects, After: r.after, Before: r.before}
+
+	for _, s := range r.sorts {
+		jr.Sort = append(jr.Sort, s.String())
+	}
+	if r.limitInt == InfiniteLimit {
+		jr.Limit = json.RawMessage(`"Infinity"`)
+	} else if r.limit != "" {
+		jr.Limit = json.RawMessage(r.limit)
+	}
+	if r.offset != "" {
+		jr.Offset = &r.offsetInt
+	}
+
+	return json.Marshal(jr)
+}
+
+// UnmarshalJSON reads a root node written by MarshalJSON. The sort, limit,
+// select and cursors are validated as the ones of a RQL query and the
+// operators arguments with the default OpSpecs (see Parser.ParseJSON to use
+// the ones of a parser).
+func (r *RqlRootNode) UnmarshalJSON(data []byte) error {
+	root, err := decodeJSONRootNode(data)
+	if err != nil {
+		return err
+	}
+	if err = NewOpSpecs().Validate(root.Node); err != nil {
+		return err
+	}
+
+	*r = root
+	return nil
+}
+
+// ParseJSON reads a root node written by RqlRootNode.MarshalJSON and validates
+// it as a parsed query (OpSpecs and field scripts of the parser)
+func (p *Parser) ParseJSON(data []byte) (*RqlRootNode, error) {
+	root, err := decodeJSONRootNode(data)
+	if err != nil {
+		return nil, err
+	}
+	if err = p.validate(&root); err != nil {
+		return nil, err
+	}
+	return &root, nil
+}
+
+// decodeJSONRootNode reads a root node without validating the operators arguments
+func decodeJSONRootNode(data []byte) (RqlRootNode, error) {
+	var jr jsonRootNode
+	if err := json.Unmarshal(data, &jr); err != nil {
+		return RqlRootNode{}, err
+	}
+
+	root := RqlRootNode{Node: jr.Query}
+
+	if len(jr.Sort) > 0 {
+		sort := &RqlNode{Op: "sort"}
+		for _, s := range jr.Sort {
+			sort.Args = append(sort.Args, s)
+		}
+		if _, err := parseSort(sort, &root); err != nil {
+			return RqlRootNode{}, err
+		}
+	}
+
+	if len(jr.Limit) > 0 {
+		var limit interface{}
+		d := json.NewDecoder(bytes.NewReader(jr.Limit))
+		d.UseNumber()
+		if err := d.Decode(&limit); err != nil {
+			return RqlRootNode{}, err
+		}
+		n := &RqlNode{Op: "limit", Args: []interface{}{fmt.Sprint(limit)}}
+		if jr.Offset != nil {
+			n.Args = append(n.Args, strconv.Itoa(*jr.Offset))
+		}
+		if _, err := parseLimit(n, &root); err != nil {
+			return RqlRootNode{}, err
+		}
+	} else if jr.Offset != nil {
+		if *jr.Offset < 0 {
+			return RqlRootNode{}, &ArgumentError{Op: "offset", Arg: *jr.Offset, Reason: "must be a non-negative integer"}
+		}
+		root.offset, root.offsetInt = strconv.Itoa(*jr.Offset), *jr.Offset
+	}
+
+	if len(jr.Select) > 0 {
+		sel := &RqlNode{Op: "select"}
+		for _, f := range jr.Select {
+			sel.Args = append(sel.Args, f)
+		}
+		if _, err := parseSelect(sel, &root); err != nil {
+			return RqlRootNode{}, err
+		}
+	}
+
+	for _, c := range []struct{ op, cursor string }{{"after", jr.After}, {"before", jr.Before}} {
+		if c.cursor == "" {
+			continue
+		}
+		if _, err := parseCursor(&RqlNode{Op: c.op, Args: []interface{}{c.cursor}}, &root); err != nil {
+			return RqlRootNode{}, err
+		}
+	}
+
+	return root, nil
+}
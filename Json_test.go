@@ -0,0 +1,149 @@
+package rqlParser
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type JSONTest struct {
+	Name             string // Name of the test
+	RQL              string // Input RQL query
+	JSON             string // Expected JSON of the root node
+	WantDecodeError  bool   // Test should raise an error when decoding the JSON (RQL is then ignored)
+	WantEncodedEqual bool   // The decoded root node must be deeply equal to the parsed one
+}
+
+func (test *JSONTest) Run(t *testing.T) {
+	if test.WantDecodeError {
+		var root RqlRootNode
+		if err := json.Unmarshal([]byte(test.JSON), &root); err == nil {
+			t.Fatalf("(%s) Expecting a decode error for %s", test.Name, test.JSON)
+		}
+		return
+	}
+
+	root, err := NewParser().Parse(strings.NewReader(test.RQL))
+	if err != nil {
+		t.Fatalf("(%s) Unexpected RQL parse error : %v", test.Name, err)
+	}
+
+	data, err := json.Marshal(root)
+	if err != nil {
+		t.Fatalf("(%s) Unexpected encode error : %v", test.Name, err)
+	}
+	if string(data) != test.JSON {
+		t.Fatalf("(%s) JSON doesn’t match the expected one %s vs %s", test.Name, data, test.JSON)
+	}
+
+	var decoded RqlRootNode
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("(%s) Unexpected decode error : %v", test.Name, err)
+	}
+	if test.WantEncodedEqual && !reflect.DeepEqual(root, &decoded) {
+		t.Fatalf("(%s) Decoded root doesn’t match the parsed one %+v vs %+v", test.Name, &decoded, root)
+	}
+
+	expected, err := NewSqlTranslator(root).Sql()
+	if err != nil {
+		t.Fatalf("(%s) Unexpected translator error : %v", test.Name, err)
+	}
+	s, err := NewSqlTranslator(&decoded).Sql()
+	if err != nil {
+		t.Fatalf("(%s) Unexpected translator error on the decoded root : %v", test.Name, err)
+	}
+	if s != expected {
+		t.Fatalf("(%s) Translated SQL of the decoded root doesn’t match %s vs %s", test.Name, s, expected)
+	}
+}
+
+var jsonTests = []JSONTest{
+	{
+		Name:             `Typed values`,
+		RQL:              `and(eq(a,1),eq(b,-2.5e3),eq(c,true),eq(d,null),eq(e,foo),eq(f,"42"),eq(g,010),eq(h,1%20),eq(i,%2B1))`,
+		JSON:             `{"query":{"name":"and","args":[{"name":"eq","args":["a",1]},{"name":"eq","args":["b",-2.5e3]},{"name":"eq","args":["c",true]},{"name":"eq","args":["d",null]},{"name":"eq","args":["e","foo"]},{"name":"eq","args":["f","42"]},{"name":"eq","args":["g","010"]},{"name":"eq","args":["h","1 "]},{"name":"eq","args":["i","+1"]}]}}`,
+		WantEncodedEqual: true,
+	},
+	{
+		Name:             `Sort, limit, offset and select`,
+		RQL:              `eq(a,1)&sort(-date,+name)&limit(20,40)&select(id,name)`,
+		JSON:             `{"query":{"name":"eq","args":["a",1]},"sort":["-date","+name"],"limit":20,"offset":40,"select":["id","name"]}`,
+		WantEncodedEqual: true,
+	},
+	{
+		Name:             `Infinite limit and cursor`,
+		RQL:              `sort(+id)&after(eyJzIjpbIitpZCJdLCJ2IjpbNV19)&limit(Infinity)`,
+		JSON:             `{"sort":["+id"],"limit":"Infinity","after":"eyJzIjpbIitpZCJdLCJ2IjpbNV19"}`,
+		WantEncodedEqual: true,
+	},
+	{
+		Name:             `Empty query`,
+		RQL:              ``,
+		JSON:             `{}`,
+		WantEncodedEqual: true,
+	},
+	{
+		Name:             `Quoted values which are not typed`,
+		RQL:              `in(a,"foo bar",foo,"x")`,
+		JSON:             `{"query":{"name":"in","args":["a",{"literal":"foo bar"},"foo",{"literal":"x"}]}}`,
+		WantEncodedEqual: true,
+	},
+	{
+		Name:            `Missing operator name`,
+		JSON:            `{"query":{"args":["a",1]}}`,
+		WantDecodeError: true,
+	},
+	{
+		Name:            `Array argument`,
+		JSON:            `{"query":{"name":"in","args":["a",[1,2]]}}`,
+		WantDecodeError: true,
+	},
+	{
+		Name:            `Invalid literal`,
+		JSON:            `{"query":{"name":"eq","args":["a",{"literal":1}]}}`,
+		WantDecodeError: true,
+	},
+	{
+		Name:            `Invalid operator arguments`,
+		JSON:            `{"query":{"name":"eq","args":["a"]}}`,
+		WantDecodeError: true,
+	},
+	{
+		Name:            `Invalid limit`,
+		JSON:            `{"limit":-1}`,
+		WantDecodeError: true,
+	},
+	{
+		Name:            `Invalid sort`,
+		JSON:            `{"sort":["-"]}`,
+		WantDecodeError: true,
+	},
+}
+
+func TestJSON(t *testing.T) {
+	for _, test := range jsonTests {
+		test.Run(t)
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	p := NewParser()
+	specs := NewOpSpecs()
+	specs.Set(OpSpec{Op: "eq", MinArgs: 1, MaxArgs: 2, Kinds: []ArgKind{FieldArg, ValueArg}})
+	p.SetOpSpecs(specs)
+
+	data := []byte(`{"query":{"name":"eq","args":["a"]},"limit":10}`)
+	root, err := p.ParseJSON(data)
+	if err != nil {
+		t.Fatalf("Unexpected error with the OpSpecs of the parser : %v", err)
+	}
+	if root.LimitInt() != 10 {
+		t.Fatalf("Decoded limit doesn’t match %d vs 10", root.LimitInt())
+	}
+
+	p.SetOpSpecs(NewOpSpecs())
+	if _, err = p.ParseJSON(data); err == nil {
+		t.Fatalf("Expecting an error with the default OpSpecs")
+	}
+}
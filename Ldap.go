@@ -0,0 +1,257 @@
+package rqlParser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ldapAttribute matches the attribute descriptors and OIDs (eg: cn, 2.5.4.3)
+var ldapAttribute = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9-]*|[0-9]+(\.[0-9]+)*)$`)
+
+// ldapEscaper escapes the special characters of the RFC 4515 assertion values
+var ldapEscaper = strings.NewReplacer(`\`, `\5c`, `*`, `\2a`, `(`, `\28`, `)`, `\29`, "\x00", `\00`)
+
+type LdapTranslator struct {
+	rootNode   *RqlRootNode
+	opsDic     map[string]TranslatorOpFunc
+	opSpecs    *OpSpecs
+	attributes map[string]string
+}
+
+func NewLdapTranslator(r *RqlRootNode) (lt *LdapTranslator) {
+	lt = &LdapTranslator{rootNode: r, opsDic: map[string]TranslatorOpFunc{}, opSpecs: NewOpSpecs(), attributes: map[string]string{}}
+
+	lt.SetOpFunc("AND", lt.GetSetTranslatorOpFunc("&"))
+	lt.SetOpFunc("OR", lt.GetSetTranslatorOpFunc("|"))
+	lt.SetOpFunc("NOT", lt.GetNotTranslatorOpFunc())
+
+	lt.SetOpFunc("EQ", lt.GetEqualityTranslatorOpFunc(false))
+	lt.SetOpFunc("NE", lt.GetEqualityTranslatorOpFunc(true))
+	lt.SetOpFunc("GE", lt.GetOrderingTranslatorOpFunc(">=", false))
+	lt.SetOpFunc("LE", lt.GetOrderingTranslatorOpFunc("<=", false))
+	lt.SetOpFunc("GT", lt.GetOrderingTranslatorOpFunc(">=", true))
+	lt.SetOpFunc("LT", lt.GetOrderingTranslatorOpFunc("<=", true))
+	lt.SetOpFunc("LIKE", lt.GetLikeTranslatorOpFunc())
+	lt.SetOpFunc("IN", lt.GetInTranslatorOpFunc(false))
+	lt.SetOpFunc("OUT", lt.GetInTranslatorOpFunc(true))
+
+	return
+}
+
+func (lt *LdapTranslator) SetOpFunc(op string, f TranslatorOpFunc) {
+	lt.opsDic[strings.ToUpper(op)] = f
+}
+
+// DeleteOpFunc removes an operator, its OpSpec is removed too
+func (lt *LdapTranslator) DeleteOpFunc(op string) {
+	delete(lt.opsDic, strings.ToUpper(op))
+	lt.opSpecs.Delete(op)
+}
+
+// SetOpSpecs sets the registry used to validate the operators arguments, nil
+// disables the validation. The registry is copied so DeleteOpFunc doesn't
+// change the given one (eg: the registry of the Parser).
+func (lt *LdapTranslator) SetOpSpecs(specs *OpSpecs) {
+	lt.opSpecs = specs.Clone()
+}
+
+// SetAttribute maps a field of the queries to an LDAP attribute (eg: email to mail)
+func (lt *LdapTranslator) SetAttribute(field, attribute string) {
+	lt.attributes[field] = attribute
+}
+
+// Filter returns the RFC 4515 filter (eg: (&(cn=jo*)(mail=*@x.com))), (objectClass=*) when the query is empty
+func (lt *LdapTranslator) Filter() (string, error) {
+	if lt.rootNode == nil || lt.rootNode.Node == nil {
+		return "(objectClass=*)", nil
+	}
+	if err := lt.opSpecs.Validate(lt.rootNode.Node); err != nil {
+		return "", err
+	}
+	return lt.where(lt.rootNode.Node)
+}
+
+func (lt *LdapTranslator) where(n *RqlNode) (string, error) {
+	f := lt.opsDic[strings.ToUpper(n.Op)]
+	if f == nil {
+		return "", fmt.Errorf("No TranslatorOpFunc for op : '%s'", n.Op)
+	}
+	return f(n)
+}
+
+// attribute returns the attribute of a field, mapped by SetAttribute or
+// used as is when it is a valid attribute descriptor
+func (lt *LdapTranslator) attribute(arg interface{}) (string, error) {
+	field, ok := arg.(string)
+	if !ok {
+		return "", fmt.Errorf("Invalid field name : %v", arg)
+	}
+	if attribute, ok := lt.attributes[field]; ok {
+		return attribute, nil
+	}
+	if !ldapAttribute.MatchString(field) {
+		return "", fmt.Errorf("Invalid field name : %v", arg)
+	}
+	return field, nil
+}
+
+// attributeValue returns the attribute and the raw value of a comparison
+func (lt *LdapTranslator) attributeValue(n *RqlNode) (string, string, error) {
+	if len(n.Args) != 2 {
+		return "", "", &ArgumentError{Op: n.Op, Reason: "requires 2 arguments"}
+	}
+	attribute, err := lt.attribute(n.Args[0])
+	if err != nil {
+		return "", "", err
+	}
+	value, err := ldapValue(n.Op, n.Args[1])
+	if err != nil {
+		return "", "", err
+	}
+	return attribute, value, nil
+}
+
+func (lt *LdapTranslator) GetSetTranslatorOpFunc(op string) TranslatorOpFunc {
+	return TranslatorOpFunc(func(n *RqlNode) (string, error) {
+		if len(n.Args) == 0 {
+			return "", &ArgumentError{Op: n.Op, Reason: "requires at least 1 argument"}
+		}
+
+		var buf strings.Builder
+		buf.WriteString("(" + op)
+		for _, a := range n.Args {
+			c, ok := a.(*RqlNode)
+			if !ok {
+				return "", &ArgumentError{Op: n.Op, Arg: a, Reason: "must be an operator"}
+			}
+			s, err := lt.where(c)
+			if err != nil {
+				return "", err
+			}
+			buf.WriteString(s)
+		}
+		buf.WriteString(")")
+		return buf.String(), nil
+	})
+}
+
+func (lt *LdapTranslator) GetNotTranslatorOpFunc() TranslatorOpFunc {
+	return TranslatorOpFunc(func(n *RqlNode) (string, error) {
+		if len(n.Args) != 1 {
+			return "", &ArgumentError{Op: n.Op, Reason: "requires 1 argument"}
+		}
+		c, ok := n.Args[0].(*RqlNode)
+		if !ok {
+			return "", &ArgumentError{Op: n.Op, Arg: n.Args[0], Reason: "must be an operator"}
+		}
+		s, err := lt.where(c)
+		if err != nil {
+			return "", err
+		}
+		return "(!" + s + ")", nil
+	})
+}
+
+// GetEqualityTranslatorOpFunc returns an equality match, the (in)equality to
+// null tests the presence of the attribute
+func (lt *LdapTranslator) GetEqualityTranslatorOpFunc(negated bool) TranslatorOpFunc {
+	return TranslatorOpFunc(func(n *RqlNode) (string, error) {
+		attribute, value, err := lt.attributeValue(n)
+		if err != nil {
+			return "", err
+		}
+
+		s, exclude := "("+attribute+"="+ldapEscaper.Replace(value)+")", negated
+		if n.Args[1] == "null" {
+			s, exclude = "("+attribute+"=*)", !negated
+		}
+		if exclude {
+			s = "(!" + s + ")"
+		}
+		return s, nil
+	})
+}
+
+// GetOrderingTranslatorOpFunc returns an ordering match (>= or <=). LDAP has
+// no strict comparison so the equality is excluded (eg: gt(a,1) is (&(a>=1)(!(a=1)))).
+func (lt *LdapTranslator) GetOrderingTranslatorOpFunc(op string, strict bool) TranslatorOpFunc {
+	return TranslatorOpFunc(func(n *RqlNode) (string, error) {
+		attribute, value, err := lt.attributeValue(n)
+		if err != nil {
+			return "", err
+		}
+		if n.Args[1] == "null" {
+			return "", &ArgumentError{Op: n.Op, Arg: value, Reason: "can't be compared"}
+		}
+
+		value = ldapEscaper.Replace(value)
+		s := "(" + attribute + op + value + ")"
+		if strict {
+			s = "(&" + s + "(!(" + attribute + "=" + value + ")))"
+		}
+		return s, nil
+	})
+}
+
+// GetLikeTranslatorOpFunc returns a substring match, the "*" of the value are wildcards
+func (lt *LdapTranslator) GetLikeTranslatorOpFunc() TranslatorOpFunc {
+	return TranslatorOpFunc(func(n *RqlNode) (string, error) {
+		attribute, pattern, err := lt.attributeValue(n)
+		if err != nil {
+			return "", err
+		}
+		if pattern == "" {
+			return "", &ArgumentError{Op: n.Op, Arg: n.Args[1], Reason: "must not be empty"}
+		}
+
+		var parts []string
+		for i, p := range strings.Split(pattern, "*") {
+			// Consecutive "*" are merged : the empty parts are only kept at the ends
+			if p == "" && i > 0 && i < strings.Count(pattern, "*") {
+				continue
+			}
+			parts = append(parts, ldapEscaper.Replace(p))
+		}
+		return "(" + attribute + "=" + strings.Join(parts, "*") + ")", nil
+	})
+}
+
+func (lt *LdapTranslator) GetInTranslatorOpFunc(negated bool) TranslatorOpFunc {
+	return TranslatorOpFunc(func(n *RqlNode) (string, error) {
+		if len(n.Args) < 2 {
+			return "", &ArgumentError{Op: n.Op, Reason: "requires at least 2 arguments"}
+		}
+		attribute, err := lt.attribute(n.Args[0])
+		if err != nil {
+			return "", err
+		}
+
+		var buf strings.Builder
+		buf.WriteString("(|")
+		for _, a := range n.Args[1:] {
+			value, err := ldapValue(n.Op, a)
+			if err != nil {
+				return "", err
+			}
+			buf.WriteString("(" + attribute + "=" + ldapEscaper.Replace(value) + ")")
+		}
+		buf.WriteString(")")
+
+		s := buf.String()
+		if negated {
+			s = "(!" + s + ")"
+		}
+		return s, nil
+	})
+}
+
+func ldapValue(op string, arg interface{}) (string, error) {
+	switch v := arg.(type) {
+	case string:
+		return v, nil
+	case StringLiteral:
+		return string(v), nil
+	}
+	return "", &ArgumentError{Op: op, Arg: arg, Reason: "must be a value"}
+}
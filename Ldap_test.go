@@ -0,0 +1,101 @@
+package rqlParser
+
+import (
+	"strings"
+	"testing"
+)
+
+type LdapTest struct {
+	Name                string            // Name of the test
+	RQL                 string            // Input RQL query
+	Attributes          map[string]string // Fields mapped to attributes
+	Filter              string            // Expected LDAP filter
+	WantTranslatorError bool              // Test should raise an error when translating
+}
+
+func (test *LdapTest) Run(t *testing.T) {
+	root, err := NewParser().Parse(strings.NewReader(test.RQL))
+	if err != nil {
+		t.Fatalf("(%s) Unexpected parse error : %v", test.Name, err)
+	}
+
+	lt := NewLdapTranslator(root)
+	for f, a := range test.Attributes {
+		lt.SetAttribute(f, a)
+	}
+
+	filter, err := lt.Filter()
+	if test.WantTranslatorError != (err != nil) {
+		t.Fatalf("(%s) Expecting error :%v\nGot error : %v", test.Name, test.WantTranslatorError, err)
+	}
+	if err != nil {
+		return
+	}
+
+	if filter != test.Filter {
+		t.Fatalf("(%s) Translated filter doesn’t match the expected one %q vs %q", test.Name, filter, test.Filter)
+	}
+}
+
+var ldapTests = []LdapTest{
+	{
+		Name:   `Sets and substrings`,
+		RQL:    `and(eq(objectClass,user),or(like(cn,jo*),like(mail,"*@x.com")))`,
+		Filter: `(&(objectClass=user)(|(cn=jo*)(mail=*@x.com)))`,
+	},
+	{
+		Name:   `Orderings and negation`,
+		RQL:    `ge(a,1)&le(b,2)&gt(c,3)&lt(d,4)&not(eq(e,x))&ne(f,y)`,
+		Filter: `(&(a>=1)(b<=2)(&(c>=3)(!(c=3)))(&(d<=4)(!(d=4)))(!(e=x))(!(f=y)))`,
+	},
+	{
+		Name:   `Presence and lists`,
+		RQL:    `eq(a,null)&ne(b,null)&eq(c,"null")&in(d,x,y)&out(e,z)`,
+		Filter: `(&(!(a=*))(b=*)(c=null)(|(d=x)(d=y))(!(|(e=z))))`,
+	},
+	{
+		Name:   `Escaped values`,
+		RQL:    `eq(cn,"a*(b)\\c")&like(sn,"*(x)*")`,
+		Filter: `(&(cn=a\2a\28b\29\5cc)(sn=*\28x\29*))`,
+	},
+	{
+		Name:   `Consecutive wildcards`,
+		RQL:    `like(a,"**")&like(b,"x**y***")&like(c,"***z")`,
+		Filter: `(&(a=*)(b=x*y*)(c=*z))`,
+	},
+	{
+		Name:       `Mapped attributes`,
+		RQL:        `eq(email,"a@x.com")&eq(user.name,bob)`,
+		Attributes: map[string]string{"email": "mail", "user.name": "uid"},
+		Filter:     `(&(mail=a@x.com)(uid=bob))`,
+	},
+	{
+		Name:   `Empty query`,
+		RQL:    ``,
+		Filter: `(objectClass=*)`,
+	},
+	{
+		Name:                `Invalid attribute`,
+		RQL:                 `eq(user.name,bob)`,
+		WantTranslatorError: true,
+	},
+	{
+		Name:                `Ordering with null`,
+		RQL:                 `gt(a,null)`,
+		WantTranslatorError: true,
+	},
+}
+
+func TestLdapTranslator(t *testing.T) {
+	for _, test := range ldapTests {
+		test.Run(t)
+	}
+}
+
+func TestLdapNulEscaping(t *testing.T) {
+	// The scanner can't read a NUL, the node is built directly
+	root := &RqlRootNode{Node: &RqlNode{Op: "eq", Args: []interface{}{"o", StringLiteral("a\x00b")}}}
+	if filter, err := NewLdapTranslator(root).Filter(); err != nil || filter != `(o=a\00b)` {
+		t.Fatalf("Unexpected filter %q (error : %v)", filter, err)
+	}
+}
@@ -2,10 +2,11 @@ package rqlParser
 
 import (
 	"bufio"
-	"bytes"
 	"fmt"
 	"io"
 	"net/url"
+	"strings"
+	"unicode"
 )
 
 const (
@@ -14,7 +15,8 @@ const (
 	EOF
 
 	// Literals
-	IDENT // fields, function names
+	IDENT  // fields, function names
+	STRING // quoted string literal
 
 	// Reserved characters
 	SPACE               //
@@ -28,20 +30,24 @@ const (
 	QUESTION_MARK       // ?
 	AT_SYMBOL           // @
 	PIPE                // |
+	EXCLAMATION_MARK    // !
+	COLON               // :
+	OPENING_BRACKET     // [
+	CLOSING_BRACKET     // ]
 
 	// Keywords
 	AND
 	OR
-	EQUAL
-	GREATER
-	GREATER_OR_EQUAL
-	LOWER
-	LOWER_OR_EQUAL
-	NOT_EQUAL
+	EQUAL            // ==
+	GREATER          // >
+	GREATER_OR_EQUAL // >=
+	LOWER            // <
+	LOWER_OR_EQUAL   // <=
+	NOT_EQUAL        // !=
 )
 
 var (
-	ReservedRunes []rune = []rune{' ', '&', '(', ')', ',', '=', '/', ';', '?', '@', '|'}
+	ReservedRunes []rune = []rune{' ', '&', '(', ')', ',', '=', '/', ';', '?', '@', '|', '!', '<', '>', ':', '[', ']'}
 	eof                  = rune(0)
 )
 
@@ -52,13 +58,15 @@ type TokenString struct {
 
 type Token int
 
+// NewTokenString returns a token unescaped as a query string value : the
+// percent encoded characters are decoded and "+" is a space
 func NewTokenString(t Token, s string) TokenString {
-	var unescapedString string
-	if len(s) > 0 && string(s[0]) != "+" {
-		unescapedString, _ = url.QueryUnescape(s)
-	} else {
-		//Golang`s "unescape" method replaces "+" with " ", however "+" literal is not possible in urlencoded string
-		//this is a case of sorting argument specification: eg: sort(+name), thus in this case string left unmodified
+	// Quoted strings are literals and are never unescaped
+	if t == STRING || !strings.ContainsAny(s, "%+") {
+		return TokenString{t: t, s: s}
+	}
+	unescapedString, err := url.QueryUnescape(s)
+	if err != nil {
 		unescapedString = s
 	}
 	return TokenString{t: t, s: unescapedString}
@@ -66,6 +74,14 @@ func NewTokenString(t Token, s string) TokenString {
 
 type Scanner struct {
 	r *bufio.Reader
+
+	// doubledQuotes escapes a quote in a string by doubling it (eg: 'it''s')
+	// instead of a backslash
+	doubledQuotes bool
+
+	// decoded is set when the input is already decoded (eg: a value of
+	// url.Values), the tokens are then never unescaped
+	decoded bool
 }
 
 func NewScanner() *Scanner {
@@ -83,17 +99,36 @@ func (s *Scanner) Scan(r io.Reader) (out []TokenString, err error) {
 		} else if tok == ILLEGAL {
 			return out, fmt.Errorf("Illegal Token : %s", lit)
 		} else {
-			out = append(out, NewTokenString(tok, lit))
+			out = append(out, s.token(tok, lit))
 		}
 	}
 
 	return
 }
 
+// token returns the TokenString of a scanned token, unescaped unless the input is decoded
+func (s *Scanner) token(t Token, lit string) TokenString {
+	if s.decoded {
+		return TokenString{t: t, s: lit}
+	}
+	return NewTokenString(t, lit)
+}
+
+// ScanToken returns the next token. Whitespaces and comments (from "#" to the
+// end of the line) between tokens are ignored.
 func (s *Scanner) ScanToken() (tok Token, lit string) {
 	ch := s.read()
 
-	if isReservedRune(ch) {
+	for isWhitespace(ch) || ch == '#' {
+		if ch == '#' {
+			s.skipComment()
+		}
+		ch = s.read()
+	}
+
+	if ch == '"' || ch == '\'' {
+		return s.scanString(ch)
+	} else if isReservedRune(ch) {
 		s.unread()
 		return s.scanReservedRune()
 	} else if isIdent(ch) {
@@ -108,6 +143,47 @@ func (s *Scanner) ScanToken() (tok Token, lit string) {
 	return ILLEGAL, string(ch)
 }
 
+// scanString reads a string literal delimited by quote. The quote and the
+// backslash are escaped with a backslash, \n, \r and \t are supported.
+// With doubledQuotes, the quote is escaped by doubling it and the backslash is a literal.
+func (s *Scanner) scanString(quote rune) (tok Token, lit string) {
+	var buf strings.Builder
+
+	for {
+		ch := s.read()
+		switch ch {
+		case eof:
+			return ILLEGAL, string(quote) + buf.String() + " (unterminated string)"
+		case quote:
+			if s.doubledQuotes && s.readIf(quote) {
+				break
+			}
+			return STRING, buf.String()
+		case '\\':
+			if s.doubledQuotes {
+				break
+			}
+			switch ch = s.read(); ch {
+			case eof:
+				return ILLEGAL, string(quote) + buf.String() + " (unterminated string)"
+			case 'n':
+				ch = '\n'
+			case 'r':
+				ch = '\r'
+			case 't':
+				ch = '\t'
+			}
+		}
+		buf.WriteRune(ch)
+	}
+}
+
+// skipComment reads the runes until the end of the line
+func (s *Scanner) skipComment() {
+	for ch := s.read(); ch != '\n' && ch != eof; ch = s.read() {
+	}
+}
+
 func (s *Scanner) read() rune {
 	ch, _, err := s.r.ReadRune()
 	if err != nil {
@@ -119,49 +195,72 @@ func (s *Scanner) read() rune {
 // unread places the previously read rune back on the reader.
 func (s *Scanner) unread() { _ = s.r.UnreadRune() }
 
-func (s *Scanner) scanReservedRune() (tok Token, lit string) {
-	// Create a buffer and read the current character into it.
-	var buf bytes.Buffer
-
-	buf.WriteRune(s.read())
-	lit = buf.String()
-
-	// Read every subsequent whitespace character into the buffer.
-	// Non-whitespace characters and EOF will cause the loop to exit.
+// readIf reads the next rune only if it is ch
+func (s *Scanner) readIf(ch rune) bool {
+	if s.read() == ch {
+		return true
+	}
+	s.unread()
+	return false
+}
 
-	for _, rr := range ReservedRunes {
-		if string(rr) == lit {
-			switch rr {
-			case '&':
-				return AMPERSAND, lit
-			case '(':
-				return OPENING_PARENTHESIS, lit
-			case ')':
-				return CLOSING_PARENTHESIS, lit
-			case ',':
-				return COMMA, lit
-			case '=':
-				return EQUAL_SIGN, lit
-			case '/':
-				return SLASH, lit
-			case ';':
-				return SEMI_COLON, lit
-			case '?':
-				return QUESTION_MARK, lit
-			case '@':
-				return AT_SYMBOL, lit
-			case '|':
-				return PIPE, lit
-			case eof:
-				return EOF, lit
-			default:
-				return ILLEGAL, lit
-			}
+func (s *Scanner) scanReservedRune() (tok Token, lit string) {
+	ch := s.read()
+	lit = string(ch)
+
+	switch ch {
+	case '&':
+		return AMPERSAND, lit
+	case '(':
+		return OPENING_PARENTHESIS, lit
+	case ')':
+		return CLOSING_PARENTHESIS, lit
+	case ',':
+		return COMMA, lit
+	case '=':
+		if s.readIf('=') {
+			return EQUAL, "=="
+		}
+		return EQUAL_SIGN, lit
+	case '!':
+		if s.readIf('=') {
+			return NOT_EQUAL, "!="
+		}
+		return EXCLAMATION_MARK, lit
+	case '<':
+		if s.readIf('=') {
+			return LOWER_OR_EQUAL, "<="
 		}
+		return LOWER, lit
+	case '>':
+		if s.readIf('=') {
+			return GREATER_OR_EQUAL, ">="
+		}
+		return GREATER, lit
+	case '/':
+		return SLASH, lit
+	case ';':
+		return SEMI_COLON, lit
+	case '?':
+		return QUESTION_MARK, lit
+	case '@':
+		return AT_SYMBOL, lit
+	case '|':
+		return PIPE, lit
+	case ':':
+		return COLON, lit
+	case '[':
+		return OPENING_BRACKET, lit
+	case ']':
+		return CLOSING_BRACKET, lit
 	}
 	return ILLEGAL, lit
 }
 
+func isWhitespace(ch rune) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
+}
+
 func isReservedRune(ch rune) bool {
 	for _, rr := range ReservedRunes {
 		if ch == rr {
@@ -172,7 +271,7 @@ func isReservedRune(ch rune) bool {
 }
 
 func isIdent(ch rune) bool {
-	return isLetter(ch) || isDigit(ch) || isSpecialChar(ch)
+	return isLetter(ch) || isDigit(ch) || isMark(ch) || isSpecialChar(ch)
 }
 
 func isSpecialChar(ch rune) bool {
@@ -180,17 +279,22 @@ func isSpecialChar(ch rune) bool {
 		ch == '+' || ch == '-' || ch == '.'
 }
 
-// isLetter returns true if the rune is a letter.
+// isLetter returns true if the rune is a unicode letter.
 func isLetter(ch rune) bool {
-	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch > unicode.MaxASCII && unicode.IsLetter(ch))
+}
+
+// isDigit returns true if the rune is a unicode decimal digit.
+func isDigit(ch rune) bool {
+	return (ch >= '0' && ch <= '9') || (ch > unicode.MaxASCII && unicode.IsDigit(ch))
 }
 
-// isDigit returns true if the rune is a digit.
-func isDigit(ch rune) bool { return (ch >= '0' && ch <= '9') }
+// isMark returns true if the rune is a unicode mark (eg: combining accent).
+func isMark(ch rune) bool { return ch > unicode.MaxASCII && unicode.IsMark(ch) }
 
 func (s *Scanner) scanIdent() (tok Token, lit string) {
 	// Create a buffer and read the current character into it.
-	var buf bytes.Buffer
+	var buf strings.Builder
 	buf.WriteRune(s.read())
 
 	// Read every subsequent ident character into the buffer.
@@ -0,0 +1,320 @@
+package rqlParser
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// luceneSpecialChars are the characters escaped by a backslash in the Lucene terms
+const luceneSpecialChars = `+-&|!(){}[]^"~*?:\/ `
+
+type LuceneTranslator struct {
+	rootNode *RqlRootNode
+	opsDic   map[string]TranslatorOpFunc
+	opSpecs  *OpSpecs
+}
+
+func NewLuceneTranslator(r *RqlRootNode) (lt *LuceneTranslator) {
+	lt = &LuceneTranslator{rootNode: r, opsDic: map[string]TranslatorOpFunc{}, opSpecs: NewOpSpecs()}
+
+	lt.SetOpFunc("AND", lt.GetAndTranslatorOpFunc())
+	lt.SetOpFunc("OR", lt.GetOrTranslatorOpFunc())
+	lt.SetOpFunc("NOT", lt.GetNotTranslatorOpFunc())
+
+	lt.SetOpFunc("EQ", lt.GetEqualityTranslatorOpFunc(false))
+	lt.SetOpFunc("NE", lt.GetEqualityTranslatorOpFunc(true))
+	lt.SetOpFunc("GT", lt.GetRangeTranslatorOpFunc("{%s TO *]"))
+	lt.SetOpFunc("GE", lt.GetRangeTranslatorOpFunc("[%s TO *]"))
+	lt.SetOpFunc("LT", lt.GetRangeTranslatorOpFunc("[* TO %s}"))
+	lt.SetOpFunc("LE", lt.GetRangeTranslatorOpFunc("[* TO %s]"))
+	lt.SetOpFunc("LIKE", lt.GetLikeTranslatorOpFunc())
+	lt.SetOpFunc("IN", lt.GetInTranslatorOpFunc(false))
+	lt.SetOpFunc("OUT", lt.GetInTranslatorOpFunc(true))
+
+	return
+}
+
+func (lt *LuceneTranslator) SetOpFunc(op string, f TranslatorOpFunc) {
+	lt.opsDic[strings.ToUpper(op)] = f
+}
+
+// DeleteOpFunc removes an operator, its OpSpec is removed too
+func (lt *LuceneTranslator) DeleteOpFunc(op string) {
+	delete(lt.opsDic, strings.ToUpper(op))
+	lt.opSpecs.Delete(op)
+}
+
+// SetOpSpecs sets the registry used to validate the operators arguments, nil
+// disables the validation. The registry is copied so DeleteOpFunc doesn't
+// change the given one (eg: the registry of the Parser).
+func (lt *LuceneTranslator) SetOpSpecs(specs *OpSpecs) {
+	lt.opSpecs = specs.Clone()
+}
+
+// Query returns the Lucene query (eg: +status:open +price:{10 TO *]), *:* when the query is empty
+func (lt *LuceneTranslator) Query() (string, error) {
+	if lt.rootNode == nil || lt.rootNode.Node == nil {
+		return "*:*", nil
+	}
+	if err := lt.opSpecs.Validate(lt.rootNode.Node); err != nil {
+		return "", err
+	}
+
+	// The top level clauses are not grouped
+	if n := lt.rootNode.Node; strings.ToUpper(n.Op) == "AND" {
+		return lt.clauses(n, "+")
+	}
+	return lt.where(lt.rootNode.Node)
+}
+
+func (lt *LuceneTranslator) where(n *RqlNode) (string, error) {
+	f := lt.opsDic[strings.ToUpper(n.Op)]
+	if f == nil {
+		return "", fmt.Errorf("No TranslatorOpFunc for op : '%s'", n.Op)
+	}
+	return f(n)
+}
+
+// Sort returns the Solr sort parameter (eg: price desc,name asc)
+func (lt *LuceneTranslator) Sort() string {
+	if lt.rootNode == nil {
+		return ""
+	}
+	sorts := make([]string, len(lt.rootNode.Sort()))
+	for i, s := range lt.rootNode.Sort() {
+		sorts[i] = escapeLucene(s.By()) + " asc"
+		if s.Desc() {
+			sorts[i] = escapeLucene(s.By()) + " desc"
+		}
+	}
+	return strings.Join(sorts, ",")
+}
+
+// Rows returns the Solr rows parameter, empty when there is no limit or an infinite one
+func (lt *LuceneTranslator) Rows() string {
+	if lt.rootNode == nil || lt.rootNode.LimitInt() == InfiniteLimit {
+		return ""
+	}
+	return lt.rootNode.Limit()
+}
+
+// Start returns the Solr start parameter
+func (lt *LuceneTranslator) Start() string {
+	if lt.rootNode == nil {
+		return ""
+	}
+	return lt.rootNode.Offset()
+}
+
+// Params returns the q, sort, rows and start parameters of a Solr request
+func (lt *LuceneTranslator) Params() (url.Values, error) {
+	q, err := lt.Query()
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{"q": {q}}
+	for k, v := range map[string]string{"sort": lt.Sort(), "rows": lt.Rows(), "start": lt.Start()} {
+		if v != "" {
+			params.Set(k, v)
+		}
+	}
+	return params, nil
+}
+
+// clauses returns the children of n joined by a space, each one prefixed
+func (lt *LuceneTranslator) clauses(n *RqlNode, prefix string) (string, error) {
+	if len(n.Args) == 0 {
+		return "", &ArgumentError{Op: n.Op, Reason: "requires at least 1 argument"}
+	}
+
+	clauses := make([]string, len(n.Args))
+	for i, a := range n.Args {
+		c, ok := a.(*RqlNode)
+		if !ok {
+			return "", &ArgumentError{Op: n.Op, Arg: a, Reason: "must be an operator"}
+		}
+		s, err := lt.where(c)
+		if err != nil {
+			return "", err
+		}
+		clauses[i] = prefix + s
+	}
+	return strings.Join(clauses, " "), nil
+}
+
+func (lt *LuceneTranslator) GetAndTranslatorOpFunc() TranslatorOpFunc {
+	return TranslatorOpFunc(func(n *RqlNode) (string, error) {
+		s, err := lt.clauses(n, "+")
+		if err != nil {
+			return "", err
+		}
+		return "(" + s + ")", nil
+	})
+}
+
+func (lt *LuceneTranslator) GetOrTranslatorOpFunc() TranslatorOpFunc {
+	return TranslatorOpFunc(func(n *RqlNode) (string, error) {
+		s, err := lt.clauses(n, "")
+		if err != nil {
+			return "", err
+		}
+		return "(" + s + ")", nil
+	})
+}
+
+// GetNotTranslatorOpFunc returns a negation matching all the other documents (eg: (*:* -status:open))
+func (lt *LuceneTranslator) GetNotTranslatorOpFunc() TranslatorOpFunc {
+	return TranslatorOpFunc(func(n *RqlNode) (string, error) {
+		if len(n.Args) != 1 {
+			return "", &ArgumentError{Op: n.Op, Reason: "requires 1 argument"}
+		}
+		s, err := lt.clauses(n, "-")
+		if err != nil {
+			return "", err
+		}
+		return "(*:* " + s + ")", nil
+	})
+}
+
+// GetEqualityTranslatorOpFunc returns a term query, the (in)equality to null
+// tests the existence of the field
+func (lt *LuceneTranslator) GetEqualityTranslatorOpFunc(negated bool) TranslatorOpFunc {
+	return TranslatorOpFunc(func(n *RqlNode) (string, error) {
+		field, value, err := luceneFieldValue(n)
+		if err != nil {
+			return "", err
+		}
+
+		s, exclude := field+":"+value, negated
+		if n.Args[1] == "null" {
+			s, exclude = field+":*", !negated
+		}
+		if exclude {
+			s = "(*:* -" + s + ")"
+		}
+		return s, nil
+	})
+}
+
+// GetRangeTranslatorOpFunc returns a range query, format holds the bounds (eg: {%s TO *])
+func (lt *LuceneTranslator) GetRangeTranslatorOpFunc(format string) TranslatorOpFunc {
+	return TranslatorOpFunc(func(n *RqlNode) (string, error) {
+		field, value, err := luceneFieldValue(n)
+		if err != nil {
+			return "", err
+		}
+		return field + ":" + fmt.Sprintf(format, value), nil
+	})
+}
+
+// GetLikeTranslatorOpFunc returns a wildcard query, the "*" of the value are wildcards
+func (lt *LuceneTranslator) GetLikeTranslatorOpFunc() TranslatorOpFunc {
+	return TranslatorOpFunc(func(n *RqlNode) (string, error) {
+		if len(n.Args) != 2 {
+			return "", &ArgumentError{Op: n.Op, Reason: "requires 2 arguments"}
+		}
+		field, err := luceneField(n.Args[0])
+		if err != nil {
+			return "", err
+		}
+
+		var pattern string
+		switch v := n.Args[1].(type) {
+		case string:
+			pattern = v
+		case StringLiteral:
+			pattern = string(v)
+		default:
+			return "", &ArgumentError{Op: n.Op, Arg: n.Args[1], Reason: "must be a value"}
+		}
+		if pattern == "" {
+			return "", &ArgumentError{Op: n.Op, Arg: n.Args[1], Reason: "must not be empty"}
+		}
+
+		parts := strings.Split(pattern, "*")
+		for i, p := range parts {
+			parts[i] = escapeLucene(p)
+		}
+		return field + ":" + strings.Join(parts, "*"), nil
+	})
+}
+
+func (lt *LuceneTranslator) GetInTranslatorOpFunc(negated bool) TranslatorOpFunc {
+	return TranslatorOpFunc(func(n *RqlNode) (string, error) {
+		if len(n.Args) < 2 {
+			return "", &ArgumentError{Op: n.Op, Reason: "requires at least 2 arguments"}
+		}
+		field, err := luceneField(n.Args[0])
+		if err != nil {
+			return "", err
+		}
+
+		values := make([]string, len(n.Args)-1)
+		for i, a := range n.Args[1:] {
+			if values[i], err = luceneValue(n.Op, a); err != nil {
+				return "", err
+			}
+		}
+
+		s := field + ":(" + strings.Join(values, " OR ") + ")"
+		if negated {
+			s = "(*:* -" + s + ")"
+		}
+		return s, nil
+	})
+}
+
+// luceneFieldValue returns the escaped field and value of a comparison
+func luceneFieldValue(n *RqlNode) (field, value string, err error) {
+	if len(n.Args) != 2 {
+		return "", "", &ArgumentError{Op: n.Op, Reason: "requires 2 arguments"}
+	}
+	if field, err = luceneField(n.Args[0]); err != nil {
+		return "", "", err
+	}
+	if value, err = luceneValue(n.Op, n.Args[1]); err != nil {
+		return "", "", err
+	}
+	return field, value, nil
+}
+
+func luceneField(arg interface{}) (string, error) {
+	field, ok := arg.(string)
+	if !ok || !IsValidField(field) {
+		return "", fmt.Errorf("Invalid field name : %v", arg)
+	}
+	return escapeLucene(field), nil
+}
+
+// luceneValue returns the escaped value, the empty values and the operators
+// keywords are quoted
+func luceneValue(op string, arg interface{}) (string, error) {
+	var s string
+	switch v := arg.(type) {
+	case string:
+		s = v
+	case StringLiteral:
+		s = string(v)
+	default:
+		return "", &ArgumentError{Op: op, Arg: arg, Reason: "must be a value"}
+	}
+	switch s {
+	case "", "AND", "OR", "NOT", "TO":
+		return `"` + s + `"`, nil
+	}
+	return escapeLucene(s), nil
+}
+
+// escapeLucene escapes the Lucene special characters with a backslash
+func escapeLucene(s string) string {
+	var buf strings.Builder
+	for _, ch := range s {
+		if strings.ContainsRune(luceneSpecialChars, ch) {
+			buf.WriteByte('\\')
+		}
+		buf.WriteRune(ch)
+	}
+	return buf.String()
+}
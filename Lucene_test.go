@@ -0,0 +1,78 @@
+package rqlParser
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type LuceneTest struct {
+	Name                string     // Name of the test
+	RQL                 string     // Input RQL query
+	Params              url.Values // Expected Solr parameters
+	WantTranslatorError bool       // Test should raise an error when translating
+}
+
+func (test *LuceneTest) Run(t *testing.T) {
+	root, err := NewParser().Parse(strings.NewReader(test.RQL))
+	if err != nil {
+		t.Fatalf("(%s) Unexpected parse error : %v", test.Name, err)
+	}
+
+	params, err := NewLuceneTranslator(root).Params()
+	if test.WantTranslatorError != (err != nil) {
+		t.Fatalf("(%s) Expecting error :%v\nGot error : %v", test.Name, test.WantTranslatorError, err)
+	}
+	if err != nil {
+		return
+	}
+
+	if !reflect.DeepEqual(params, test.Params) {
+		t.Fatalf("(%s) Translated parameters don’t match the expected ones %v vs %v", test.Name, params, test.Params)
+	}
+}
+
+var luceneTests = []LuceneTest{
+	{
+		Name: `Top level clauses, sort, rows and start`,
+		RQL:  `eq(status,open)&gt(price,10)&sort(-price,+name)&limit(20,40)`,
+		Params: url.Values{
+			"q":     {`+status:open +price:{10 TO *]`},
+			"sort":  {`price desc,name asc`},
+			"rows":  {`20`},
+			"start": {`40`},
+		},
+	},
+	{
+		Name:   `Ranges and grouping`,
+		RQL:    `or(and(ge(a,1),le(a,5)),lt(b,-2),not(eq(c,x)))`,
+		Params: url.Values{"q": {`((+a:[1 TO *] +a:[* TO 5]) b:[* TO \-2} (*:* -c:x))`}},
+	},
+	{
+		Name:   `Wildcards, lists and null`,
+		RQL:    `like(name,"Chris*(x)")&in(tag,a,"b c")&out(type,OR)&eq(deleted,null)&ne(owner,null)&ne(d,1)`,
+		Params: url.Values{"q": {`+name:Chris*\(x\) +tag:(a OR b\ c) +(*:* -type:("OR")) +(*:* -deleted:*) +owner:* +(*:* -d:1)`}},
+	},
+	{
+		Name:   `Escaped values and quoted null`,
+		RQL:    `eq(path,"a/b:c")&eq(n,"null")&limit(Infinity)`,
+		Params: url.Values{"q": {`+path:a\/b\:c +n:null`}},
+	},
+	{
+		Name:   `Empty query`,
+		RQL:    ``,
+		Params: url.Values{"q": {`*:*`}},
+	},
+	{
+		Name:                `Unsupported operator`,
+		RQL:                 `match(a,b)`,
+		WantTranslatorError: true,
+	},
+}
+
+func TestLuceneTranslator(t *testing.T) {
+	for _, test := range luceneTests {
+		test.Run(t)
+	}
+}
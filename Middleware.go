@@ -0,0 +1,103 @@
+package rqlParser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type contextKey int
+
+const rqlRootNodeKey contextKey = 0
+
+// ErrorHandlerFunc is called by the Middleware when the RQL query of a request can't be parsed
+type ErrorHandlerFunc func(w http.ResponseWriter, r *http.Request, err error)
+
+// Middleware parses the RQL query of the incoming requests and stores the
+// resulting RqlRootNode in the request context (see FromContext)
+type Middleware struct {
+	// Parser used to parse the queries
+	Parser *Parser
+	// QueryParam is the name of the query parameter holding the RQL query,
+	// its value is decoded once (eg: a%2Bb is a+b). When empty, the whole raw
+	// query string is parsed.
+	QueryParam string
+	// MaxQueryLength is the maximum length (in bytes) of the RQL query. 0 means no limit.
+	MaxQueryLength int
+	// ErrorHandler writes the response when the query is invalid
+	ErrorHandler ErrorHandlerFunc
+}
+
+func NewMiddleware() *Middleware {
+	return &Middleware{
+		Parser:       NewParser(),
+		ErrorHandler: WriteJSONError,
+	}
+}
+
+// Handler returns a http.Handler parsing the RQL query before calling next
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		root, err := m.parseRequest(r)
+		if err != nil {
+			errorHandler := m.ErrorHandler
+			if errorHandler == nil {
+				errorHandler = WriteJSONError
+			}
+			errorHandler(w, r, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), root)))
+	})
+}
+
+func (m *Middleware) parseRequest(r *http.Request) (*RqlRootNode, error) {
+	query := r.URL.RawQuery
+	if m.QueryParam != "" {
+		query = r.URL.Query().Get(m.QueryParam)
+	}
+
+	if m.MaxQueryLength > 0 && len(query) > m.MaxQueryLength {
+		return nil, fmt.Errorf("Query exceeds the maximum length of %d bytes", m.MaxQueryLength)
+	}
+
+	p := m.Parser
+	if p == nil {
+		p = NewParser()
+	}
+
+	if m.QueryParam != "" {
+		// The parameter value is already decoded by URL.Query
+		return p.parse(strings.NewReader(query), true)
+	}
+
+	// The raw query string may contain non RQL parameters (see Parser.SetReservedParams)
+	return p.ParseQuery(query)
+}
+
+// NewContext returns a copy of ctx holding the RqlRootNode
+func NewContext(ctx context.Context, root *RqlRootNode) context.Context {
+	return context.WithValue(ctx, rqlRootNodeKey, root)
+}
+
+// FromContext returns the RqlRootNode stored in ctx by the Middleware
+func FromContext(ctx context.Context) (root *RqlRootNode, ok bool) {
+	root, ok = ctx.Value(rqlRootNodeKey).(*RqlRootNode)
+	return
+}
+
+// JSONError is the body written by WriteJSONError
+type JSONError struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
+// WriteJSONError writes a 400 Bad Request response with a JSONError body
+func WriteJSONError(w http.ResponseWriter, r *http.Request, err error) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(JSONError{Error: "invalid_query", Message: err.Error()})
+}
@@ -0,0 +1,94 @@
+package rqlParser
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type MiddlewareTest struct {
+	Name       string // Name of the test
+	URL        string // Requested URL
+	QueryParam string // Query parameter holding the RQL query
+	MaxLength  int    // Maximum query length
+	SQL        string // Expected SQL translated from the context node
+	WantStatus int    // Expected response status
+}
+
+func (test *MiddlewareTest) Run(t *testing.T) {
+	m := NewMiddleware()
+	m.QueryParam = test.QueryParam
+	m.MaxQueryLength = test.MaxLength
+
+	var sql string
+	h := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		root, ok := FromContext(r.Context())
+		if !ok {
+			t.Fatalf("(%s) No RqlRootNode in context", test.Name)
+		}
+		var err error
+		if sql, err = NewSqlTranslator(root).Sql(); err != nil {
+			t.Fatalf("(%s) Unexpected translator error : %v", test.Name, err)
+		}
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", test.URL, nil))
+
+	if w.Code != test.WantStatus {
+		t.Fatalf("(%s) Expecting status %d, got %d", test.Name, test.WantStatus, w.Code)
+	}
+
+	if w.Code != http.StatusOK {
+		var body JSONError
+		if err := json.NewDecoder(w.Body).Decode(&body); err != nil || body.Message == "" {
+			t.Fatalf("(%s) Invalid JSON error body (%v)", test.Name, err)
+		}
+		return
+	}
+
+	if sql != test.SQL {
+		t.Fatalf("(%s) Translated SQL doesn’t match the expected one %s vs %s", test.Name, sql, test.SQL)
+	}
+}
+
+var middlewareTests = []MiddlewareTest{
+	{
+		Name:       `Raw query string`,
+		URL:        `/items?eq(foo,42)&sort(-price)`,
+		SQL:        `WHERE (foo = 42) ORDER BY price DESC`,
+		WantStatus: http.StatusOK,
+	},
+	{
+		Name:       `Query parameter`,
+		URL:        `/items?q=and(eq(foo,42),gt(price,10))&page=2`,
+		QueryParam: `q`,
+		SQL:        `WHERE ((foo = 42) AND (price > 10))`,
+		WantStatus: http.StatusOK,
+	},
+	{
+		Name:       `Encoded query parameter`,
+		URL:        `/items?q=and(eq(name,a%2Bb),eq(rate,50%25),eq(title,"a+b"))`,
+		QueryParam: `q`,
+		SQL:        `WHERE ((name = 'a+b') AND (rate = '50%') AND (title = 'a b'))`,
+		WantStatus: http.StatusOK,
+	},
+	{
+		Name:       `Invalid query`,
+		URL:        `/items?and(not(test),eq(foo,toto)gt(price,10))`,
+		WantStatus: http.StatusBadRequest,
+	},
+	{
+		Name:       `Query too long`,
+		URL:        `/items?eq(foo,42)`,
+		MaxLength:  5,
+		WantStatus: http.StatusBadRequest,
+	},
+}
+
+func TestMiddleware(t *testing.T) {
+	for _, test := range middlewareTests {
+		test.Run(t)
+	}
+}
@@ -0,0 +1,358 @@
+package rqlParser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// MongoQuery is a Mongo style find query. Sort is an ordered document (eg:
+// {"date":-1,"name":1}) so it is kept raw.
+type MongoQuery struct {
+	Filter json.RawMessage `json:"filter,omitempty"`
+	Sort   json.RawMessage `json:"sort,omitempty"`
+	Skip   *int            `json:"skip,omitempty"`
+	Limit  *int            `json:"limit,omitempty"` // 0 means no limit
+}
+
+// mongoMember is a member of a decoded Mongo document
+type mongoMember struct {
+	key   string
+	value interface{}
+}
+
+// mongoFieldOps maps the Mongo comparison operators to the RQL operators
+var mongoFieldOps = map[string]string{
+	"$eq":  "eq",
+	"$ne":  "ne",
+	"$gt":  "gt",
+	"$gte": "ge",
+	"$lt":  "lt",
+	"$lte": "le",
+	"$in":  "in",
+	"$nin": "out",
+}
+
+// ParseMongo parses a Mongo style find query :
+// {"filter":{"price":{"$gt":10}},"sort":{"date":-1},"skip":40,"limit":20}
+func (p *Parser) ParseMongo(data []byte) (*RqlRootNode, error) {
+	var q MongoQuery
+	if err := json.Unmarshal(data, &q); err != nil {
+		return nil, err
+	}
+	return p.ParseMongoQuery(q)
+}
+
+// ParseMongoFilter parses a Mongo style filter document (eg: {"$and":[{"price":{"$gt":10}}]})
+func (p *Parser) ParseMongoFilter(filter []byte) (*RqlRootNode, error) {
+	return p.ParseMongoQuery(MongoQuery{Filter: filter})
+}
+
+// ParseMongoQuery converts a Mongo style find query. The supported operators
+// are $and, $or, $nor, $not, $eq, $ne, $gt, $gte, $lt, $lte, $in, $nin and
+// $exists. The JSON strings are converted to StringLiteral.
+func (p *Parser) ParseMongoQuery(q MongoQuery) (*RqlRootNode, error) {
+	ps := &parser{maxDepth: p.maxDepth}
+	root := &RqlRootNode{}
+
+	if len(bytes.TrimSpace(q.Filter)) > 0 {
+		doc, err := ps.decodeMongoDocument(q.Filter)
+		if err != nil {
+			return nil, err
+		}
+		if root.Node, err = ps.mongoFilter(doc); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(bytes.TrimSpace(q.Sort)) > 0 {
+		doc, err := ps.decodeMongoDocument(q.Sort)
+		if err != nil {
+			return nil, err
+		}
+		sort := &RqlNode{Op: "sort"}
+		for _, m := range doc {
+			switch fmt.Sprint(m.value) {
+			case "1":
+				sort.Args = append(sort.Args, "+"+m.key)
+			case "-1":
+				sort.Args = append(sort.Args, "-"+m.key)
+			default:
+				return nil, &ArgumentError{Op: "sort", Arg: m.value, Reason: "must be 1 or -1"}
+			}
+		}
+		if len(sort.Args) > 0 {
+			if _, err := parseSort(sort, root); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if q.Limit != nil && *q.Limit != 0 {
+		limit := &RqlNode{Op: "limit", Args: []interface{}{strconv.Itoa(*q.Limit)}}
+		if q.Skip != nil {
+			limit.Args = append(limit.Args, strconv.Itoa(*q.Skip))
+		}
+		if _, err := parseLimit(limit, root); err != nil {
+			return nil, err
+		}
+	} else if q.Skip != nil {
+		if *q.Skip < 0 {
+			return nil, &ArgumentError{Op: "skip", Arg: *q.Skip, Reason: "must be a non-negative integer"}
+		}
+		root.offset, root.offsetInt = strconv.Itoa(*q.Skip), *q.Skip
+	}
+
+	if err := p.validate(root); err != nil {
+		return nil, err
+	}
+
+	return root, nil
+}
+
+// mongoFilter returns the node of a filter document, its members are and-ed
+func (p *parser) mongoFilter(doc []mongoMember) (*RqlNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	var args []interface{}
+
+	for _, m := range doc {
+		var (
+			n   *RqlNode
+			err error
+		)
+		switch m.key {
+		case "$and", "$or", "$nor":
+			n, err = p.mongoLogical(m)
+		default:
+			if len(m.key) > 0 && m.key[0] == '$' {
+				return nil, fmt.Errorf("Unsupported Mongo operator : %s", m.key)
+			}
+			n, err = p.mongoField(m.key, m.value)
+		}
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, n)
+	}
+
+	switch len(args) {
+	case 0:
+		return nil, nil
+	case 1:
+		return args[0].(*RqlNode), nil
+	}
+	return &RqlNode{Op: "AND", Args: args}, nil
+}
+
+// mongoLogical returns the node of $and, $or and $nor
+func (p *parser) mongoLogical(m mongoMember) (*RqlNode, error) {
+	docs, ok := m.value.([]interface{})
+	if !ok || len(docs) == 0 {
+		return nil, &ArgumentError{Op: m.key, Reason: "requires a non-empty array"}
+	}
+
+	op := "AND"
+	if m.key != "$and" {
+		op = "OR"
+	}
+	n := &RqlNode{Op: op}
+
+	for _, d := range docs {
+		doc, ok := d.([]mongoMember)
+		if !ok {
+			return nil, &ArgumentError{Op: m.key, Arg: d, Reason: "must be a document"}
+		}
+		c, err := p.mongoFilter(doc)
+		if err != nil {
+			return nil, err
+		}
+		if c == nil {
+			return nil, &ArgumentError{Op: m.key, Arg: "{}", Reason: "must not be empty"}
+		}
+		n.Args = append(n.Args, c)
+	}
+
+	if len(n.Args) == 1 {
+		n = n.Args[0].(*RqlNode)
+	}
+	if m.key == "$nor" {
+		n = &RqlNode{Op: "not", Args: []interface{}{n}}
+	}
+	return n, nil
+}
+
+// mongoField returns the node of a field condition : a value (equality) or
+// an operators document (eg: {"$gt":10,"$lt":20}) whose operators are and-ed
+func (p *parser) mongoField(field string, value interface{}) (*RqlNode, error) {
+	if !IsValidField(field) {
+		return nil, fmt.Errorf("Invalid field name : %s", field)
+	}
+
+	doc, ok := value.([]mongoMember)
+	if !ok {
+		v, err := mongoValue("$eq", value)
+		if err != nil {
+			return nil, err
+		}
+		return &RqlNode{Op: "eq", Args: []interface{}{field, v}}, nil
+	}
+	if len(doc) == 0 || doc[0].key == "" || doc[0].key[0] != '$' {
+		return nil, &ArgumentError{Op: "$eq", Arg: field, Reason: "embedded documents are not supported"}
+	}
+
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	var args []interface{}
+	for _, m := range doc {
+		n, err := p.mongoFieldOp(field, m)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, n)
+	}
+
+	if len(args) == 1 {
+		return args[0].(*RqlNode), nil
+	}
+	return &RqlNode{Op: "AND", Args: args}, nil
+}
+
+func (p *parser) mongoFieldOp(field string, m mongoMember) (*RqlNode, error) {
+	switch m.key {
+	case "$not":
+		c, err := p.mongoField(field, m.value)
+		if err != nil {
+			return nil, err
+		}
+		return &RqlNode{Op: "not", Args: []interface{}{c}}, nil
+	case "$exists":
+		exists, ok := m.value.(bool)
+		if !ok {
+			return nil, &ArgumentError{Op: m.key, Arg: m.value, Reason: "must be a boolean"}
+		}
+		if exists {
+			return &RqlNode{Op: "ne", Args: []interface{}{field, "null"}}, nil
+		}
+		return &RqlNode{Op: "eq", Args: []interface{}{field, "null"}}, nil
+	}
+
+	op, ok := mongoFieldOps[m.key]
+	if !ok {
+		return nil, fmt.Errorf("Unsupported Mongo operator : %s", m.key)
+	}
+	n := &RqlNode{Op: op, Args: []interface{}{field}}
+
+	if op == "in" || op == "out" {
+		values, ok := m.value.([]interface{})
+		if !ok || len(values) == 0 {
+			return nil, &ArgumentError{Op: m.key, Reason: "requires a non-empty array"}
+		}
+		for _, value := range values {
+			v, err := mongoValue(m.key, value)
+			if err != nil {
+				return nil, err
+			}
+			n.Args = append(n.Args, v)
+		}
+		return n, nil
+	}
+
+	v, err := mongoValue(m.key, m.value)
+	if err != nil {
+		return nil, err
+	}
+	n.Args = append(n.Args, v)
+	return n, nil
+}
+
+// mongoValue converts a JSON scalar to an argument
+func mongoValue(op string, value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case nil:
+		return "null", nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case json.Number:
+		return v.String(), nil
+	case string:
+		return StringLiteral(v), nil
+	}
+	return nil, &ArgumentError{Op: op, Arg: value, Reason: "must be a string, a number, a boolean or null"}
+}
+
+// decodeMongoDocument decodes a JSON object keeping the order of its members
+func (p *parser) decodeMongoDocument(data []byte) ([]mongoMember, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+
+	v, err := p.decodeMongoValue(d)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = d.Token(); err == nil {
+		return nil, fmt.Errorf("Invalid Mongo document : unexpected data after the document")
+	}
+	doc, ok := v.([]mongoMember)
+	if !ok {
+		return nil, fmt.Errorf("Invalid Mongo document : %s", data)
+	}
+	return doc, nil
+}
+
+// decodeMongoValue decodes the next JSON value. The objects are decoded as
+// []mongoMember and the arrays as []interface{}.
+func (p *parser) decodeMongoValue(d *json.Decoder) (interface{}, error) {
+	t, err := d.Token()
+	if err != nil {
+		return nil, fmt.Errorf("Invalid Mongo document : %s", err)
+	}
+
+	delim, ok := t.(json.Delim)
+	if !ok {
+		return t, nil
+	}
+
+	if err = p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	switch delim {
+	case '{':
+		doc := []mongoMember{}
+		for d.More() {
+			t, err := d.Token()
+			if err != nil {
+				return nil, fmt.Errorf("Invalid Mongo document : %s", err)
+			}
+			value, err := p.decodeMongoValue(d)
+			if err != nil {
+				return nil, err
+			}
+			doc = append(doc, mongoMember{key: t.(string), value: value})
+		}
+		_, err = d.Token()
+		return doc, err
+	case '[':
+		values := []interface{}{}
+		for d.More() {
+			value, err := p.decodeMongoValue(d)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		_, err = d.Token()
+		return values, err
+	}
+
+	return nil, fmt.Errorf("Invalid Mongo document : unexpected %s", delim)
+}
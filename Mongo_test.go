@@ -0,0 +1,120 @@
+package rqlParser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type MongoTest struct {
+	Name           string // Name of the test
+	Mongo          string // Input Mongo style find query
+	RQL            string // Equivalent RQL query producing the same root node
+	SQL            string // Expected Output SQL
+	WantParseError bool   // Test should raise an error when parsing the Mongo query
+}
+
+func (test *MongoTest) Run(t *testing.T) {
+	root, err := NewParser().ParseMongo([]byte(test.Mongo))
+	if test.WantParseError != (err != nil) {
+		t.Fatalf("(%s) Expecting error :%v\nGot error : %v", test.Name, test.WantParseError, err)
+	}
+	if err != nil {
+		return
+	}
+
+	if test.RQL != "" {
+		rqlRoot, err := NewParser().Parse(strings.NewReader(test.RQL))
+		if err != nil {
+			t.Fatalf("(%s) Unexpected RQL parse error : %v", test.Name, err)
+		}
+		if !reflect.DeepEqual(root, rqlRoot) {
+			t.Fatalf("(%s) Mongo root doesn’t match the RQL one %+v vs %+v", test.Name, root, rqlRoot)
+		}
+	}
+
+	s, err := NewSqlTranslator(root).Sql()
+	if err != nil {
+		t.Fatalf("(%s) Unexpected translator error : %v", test.Name, err)
+	}
+	if s != test.SQL {
+		t.Fatalf("(%s) Translated SQL doesn’t match the expected one %s vs %s", test.Name, s, test.SQL)
+	}
+}
+
+var mongoTests = []MongoTest{
+	{
+		Name:  `Filter, sort, skip and limit`,
+		Mongo: `{"filter":{"$and":[{"price":{"$gt":10}},{"status":"open"}]},"sort":{"date":-1,"name":1},"skip":40,"limit":20}`,
+		RQL:   `(gt(price,10)&eq(status,"open"))&sort(-date,+name)&limit(20,40)`,
+		SQL:   `WHERE ((price > 10) AND (status = 'open')) ORDER BY date DESC, name LIMIT 20 OFFSET 40`,
+	},
+	{
+		Name:  `Implicit and, operators and typed values`,
+		Mongo: `{"filter":{"a":{"$gte":1,"$lte":2.5},"b":{"$ne":null},"c":true,"d":{"$in":["x",1]},"e":{"$nin":["42"]},"f":{"$lt":-3}}}`,
+		RQL:   `(ge(a,1)&le(a,2.5))&ne(b,null)&eq(c,true)&in(d,"x",1)&out(e,"42")&lt(f,-3)`,
+		SQL:   `WHERE (((a >= 1) AND (a <= '2.5')) AND (b IS NOT NULL) AND (c IS TRUE) AND (d IN ('x', 1)) AND (e NOT IN ('42')) AND (f < -3))`,
+	},
+	{
+		Name:  `Or, nor, not and exists`,
+		Mongo: `{"filter":{"$or":[{"a":1},{"b":{"$exists":false}}],"$nor":[{"c":2}],"d":{"$not":{"$eq":3}},"e":{"$exists":true}}}`,
+		RQL:   `(eq(a,1)|eq(b,null))&not(eq(c,2))&not(eq(d,3))&ne(e,null)`,
+		SQL:   `WHERE (((a = 1) OR (b IS NULL)) AND NOT((c = 2)) AND NOT((d = 3)) AND (e IS NOT NULL))`,
+	},
+	{
+		Name:  `Skip without limit and limit 0`,
+		Mongo: `{"filter":{},"skip":10,"limit":0}`,
+		SQL:   ` OFFSET 10`,
+	},
+	{
+		Name:           `Unsupported operator`,
+		Mongo:          `{"filter":{"a":{"$regex":"^x"}}}`,
+		WantParseError: true,
+	},
+	{
+		Name:           `Unsupported top level operator`,
+		Mongo:          `{"filter":{"$where":"this.a > 1"}}`,
+		WantParseError: true,
+	},
+	{
+		Name:           `Embedded document`,
+		Mongo:          `{"filter":{"a":{"b":1}}}`,
+		WantParseError: true,
+	},
+	{
+		Name:           `Invalid field name`,
+		Mongo:          `{"filter":{"a b":1}}`,
+		WantParseError: true,
+	},
+	{
+		Name:           `Invalid sort direction`,
+		Mongo:          `{"sort":{"a":2}}`,
+		WantParseError: true,
+	},
+	{
+		Name:           `Empty or`,
+		Mongo:          `{"filter":{"$or":[]}}`,
+		WantParseError: true,
+	},
+	{
+		Name:           `Invalid JSON`,
+		Mongo:          `{"filter":{"a":}}`,
+		WantParseError: true,
+	},
+}
+
+func TestMongo(t *testing.T) {
+	for _, test := range mongoTests {
+		test.Run(t)
+	}
+}
+
+func TestMongoMaxDepth(t *testing.T) {
+	p := NewParser()
+	p.SetMaxDepth(10)
+
+	filter := strings.Repeat(`{"$and":[`, 20) + `{"a":1}` + strings.Repeat(`]}`, 20)
+	if _, err := p.ParseMongoFilter([]byte(filter)); err == nil {
+		t.Fatalf("Expecting a maximum depth error")
+	}
+}
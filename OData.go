@@ -0,0 +1,513 @@
+package rqlParser
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// odataComparisonOps maps the OData comparison operators to the RQL operators
+var odataComparisonOps = map[string]string{
+	"eq": "eq",
+	"ne": "ne",
+	"gt": "gt",
+	"ge": "ge",
+	"lt": "lt",
+	"le": "le",
+}
+
+// odataFunctions maps the OData string functions to the like patterns
+var odataFunctions = map[string]func(string) string{
+	"contains":   func(s string) string { return "*" + s + "*" },
+	"startswith": func(s string) string { return s + "*" },
+	"endswith":   func(s string) string { return "*" + s },
+}
+
+var (
+	// odataDateTimePrefix matches the date and hour of a DateTimeOffset
+	// literal, its next parts are separated by colons (eg: 2024-01-01T10:00:00Z)
+	odataDateTimePrefix = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}$`)
+
+	// odataNumber matches the unquoted numbers (eg: 42, -1.5e3 or 007)
+	odataNumber = regexp.MustCompile(`^[+-]?[0-9]+(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+	// odataDateTime matches the Date and DateTimeOffset literals
+	odataDateTime = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}(T[0-9]{2}:[0-9]{2}(:[0-9]{2}(\.[0-9]+)?)?(Z|[+-][0-9]{2}:[0-9]{2}))?$`)
+)
+
+// ParseOData parses the OData system query options $filter, $orderby, $top,
+// $skip and $select. The other parameters are ignored. The property paths
+// (eg: Address/City) are converted to dotted fields (eg: Address.City).
+func (p *Parser) ParseOData(values url.Values) (*RqlRootNode, error) {
+	root := &RqlRootNode{}
+
+	if filter := values.Get("$filter"); filter != "" {
+		ps := &parser{s: NewScanner(), maxDepth: p.maxDepth}
+		ps.s.r = bufio.NewReader(strings.NewReader(filter))
+		ps.s.doubledQuotes = true
+		// The values are already decoded
+		ps.s.decoded = true
+
+		var err error
+		if root.Node, err = ps.parseODataFilter(); err != nil {
+			return nil, err
+		}
+	}
+
+	if orderBy := values.Get("$orderby"); orderBy != "" {
+		sort := &RqlNode{Op: "sort"}
+		for _, key := range strings.Split(orderBy, ",") {
+			words := strings.Fields(key)
+			if len(words) == 0 || len(words) > 2 {
+				return nil, &ArgumentError{Op: "$orderby", Arg: key, Reason: "must be a property with an optional direction"}
+			}
+			field := odataField(words[0])
+			if len(words) == 2 {
+				switch strings.ToLower(words[1]) {
+				case "asc":
+				case "desc":
+					field = "-" + field
+				default:
+					return nil, &ArgumentError{Op: "$orderby", Arg: key, Reason: "direction must be asc or desc"}
+				}
+			}
+			sort.Args = append(sort.Args, field)
+		}
+		if _, err := parseSort(sort, root); err != nil {
+			return nil, err
+		}
+	}
+
+	top, skip := values.Get("$top"), values.Get("$skip")
+	if top != "" {
+		limit := &RqlNode{Op: "limit", Args: []interface{}{top}}
+		if skip != "" {
+			limit.Args = append(limit.Args, skip)
+		}
+		if _, err := parseLimit(limit, root); err != nil {
+			return nil, err
+		}
+	} else if skip != "" {
+		offset, err := parseNonNegativeInt("$skip", skip)
+		if err != nil {
+			return nil, err
+		}
+		root.offset, root.offsetInt = strconv.Itoa(offset), offset
+	}
+
+	if sel := values.Get("$select"); sel != "" {
+		selectNode := &RqlNode{Op: "select"}
+		for _, field := range strings.Split(sel, ",") {
+			selectNode.Args = append(selectNode.Args, odataField(strings.TrimSpace(field)))
+		}
+		if _, err := parseSelect(selectNode, root); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := p.validate(root); err != nil {
+		return nil, err
+	}
+
+	return root, nil
+}
+
+// parseODataFilter parses a $filter expression. The grammar is :
+//
+//	filter     = or EOF
+//	or         = and { "or" and }
+//	and        = unary { "and" unary }
+//	unary      = "not" unary | "(" or ")" | function | comparison
+//	function   = ( "contains" | "startswith" | "endswith" ) "(" property "," value ")"
+//	comparison = property ( comparator value | "in" "(" value { "," value } ")" )
+//	comparator = "eq" | "ne" | "gt" | "ge" | "lt" | "le"
+//	property   = IDENT { "/" IDENT }
+//	value      = IDENT | STRING
+//
+// The string literals are single quoted and a quote is escaped by doubling it.
+func (p *parser) parseODataFilter() (*RqlNode, error) {
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	n, err := p.parseODataOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.t != EOF {
+		return nil, p.unexpected()
+	}
+
+	return n, nil
+}
+
+func (p *parser) parseODataOr() (*RqlNode, error) {
+	return p.parseRSQLList("OR", func() bool {
+		return p.isRSQLKeyword("or")
+	}, p.parseODataAnd)
+}
+
+func (p *parser) parseODataAnd() (*RqlNode, error) {
+	return p.parseRSQLList("AND", func() bool {
+		return p.isRSQLKeyword("and")
+	}, p.parseODataUnary)
+}
+
+func (p *parser) parseODataUnary() (*RqlNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	if p.tok.t == OPENING_PARENTHESIS {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		n, err := p.parseODataOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.t != CLOSING_PARENTHESIS {
+			return nil, fmt.Errorf("Missing closing parenthesis")
+		}
+		return n, p.next()
+	}
+
+	if p.tok.t != IDENT {
+		return nil, p.unexpected()
+	}
+
+	next, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+
+	// A "not" followed by a comparison operator is a property name
+	if _, isComparator := odataComparisonOps[strings.ToLower(next.s)]; p.isRSQLKeyword("not") && !(next.t == IDENT && isComparator) {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		n, err := p.parseODataUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &RqlNode{Op: "not", Args: []interface{}{n}}, nil
+	}
+
+	if next.t == OPENING_PARENTHESIS {
+		return p.parseODataFunction()
+	}
+
+	return p.parseODataComparison()
+}
+
+func (p *parser) parseODataFunction() (*RqlNode, error) {
+	name := p.tok.s
+	pattern, ok := odataFunctions[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("Unsupported OData function : %s", name)
+	}
+
+	// Skip the opening parenthesis
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	field, err := p.parseODataProperty()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.t != COMMA {
+		return nil, fmt.Errorf("Function %s requires 2 arguments", name)
+	}
+	if err = p.next(); err != nil {
+		return nil, err
+	}
+	if p.tok.t != STRING {
+		return nil, fmt.Errorf("Second argument of function %s must be a string", name)
+	}
+	if strings.Contains(p.tok.s, "*") {
+		return nil, &ArgumentError{Op: name, Arg: p.tok.s, Reason: "must not contain a '*'"}
+	}
+	value := StringLiteral(pattern(p.tok.s))
+	if err = p.next(); err != nil {
+		return nil, err
+	}
+	if p.tok.t != CLOSING_PARENTHESIS {
+		return nil, fmt.Errorf("Function %s requires 2 arguments", name)
+	}
+
+	return &RqlNode{Op: "like", Args: []interface{}{field, value}}, p.next()
+}
+
+func (p *parser) parseODataComparison() (*RqlNode, error) {
+	field, err := p.parseODataProperty()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.isRSQLKeyword("in") {
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+		if p.tok.t != OPENING_PARENTHESIS {
+			return nil, p.unexpected()
+		}
+		values, err := p.parseRSQLArguments()
+		if err != nil {
+			return nil, err
+		}
+		return &RqlNode{Op: "in", Args: append([]interface{}{field}, values...)}, nil
+	}
+
+	op, ok := odataComparisonOps[strings.ToLower(p.tok.s)]
+	if p.tok.t != IDENT || !ok {
+		return nil, fmt.Errorf("Missing comparison operator after %s", field)
+	}
+	if err = p.next(); err != nil {
+		return nil, err
+	}
+
+	value, err := p.parseODataValue()
+	if err != nil {
+		return nil, err
+	}
+
+	return &RqlNode{Op: op, Args: []interface{}{field, value}}, nil
+}
+
+// parseODataValue reads a value, the unquoted DateTimeOffset literals are
+// read as a single value (eg: 2024-01-01T10:00:00Z)
+func (p *parser) parseODataValue() (interface{}, error) {
+	value, err := p.parseRSQLValue()
+	if err != nil {
+		return nil, err
+	}
+	s, ok := value.(string)
+	if !ok || !odataDateTimePrefix.MatchString(s) {
+		return value, nil
+	}
+
+	for p.tok.t == COLON {
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+		if p.tok.t != IDENT {
+			return nil, p.unexpected()
+		}
+		s += ":" + p.tok.s
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+	}
+	if !odataDateTime.MatchString(s) {
+		return nil, fmt.Errorf("Invalid date/time literal : %s", s)
+	}
+	return s, nil
+}
+
+// parseODataProperty reads a property path (eg: Address/City) and returns it as a dotted field
+func (p *parser) parseODataProperty() (string, error) {
+	var segments []string
+	for {
+		if p.tok.t != IDENT {
+			return "", p.unexpected()
+		}
+		segments = append(segments, p.tok.s)
+		if err := p.next(); err != nil {
+			return "", err
+		}
+		if p.tok.t != SLASH {
+			return strings.Join(segments, "."), nil
+		}
+		if err := p.next(); err != nil {
+			return "", err
+		}
+	}
+}
+
+func odataField(property string) string {
+	return strings.Replace(property, "/", ".", -1)
+}
+
+// FormatOData returns the OData system query options of the root node. The
+// unquoted values which are numbers, booleans or null are written as is, the
+// other values are written as string literals.
+func FormatOData(r *RqlRootNode) (url.Values, error) {
+	values := url.Values{}
+
+	if r.Node != nil {
+		filter, err := formatODataFilter(r.Node)
+		if err != nil {
+			return nil, err
+		}
+		values.Set("$filter", filter)
+	}
+
+	if len(r.sorts) > 0 {
+		keys := make([]string, len(r.sorts))
+		for i, s := range r.sorts {
+			keys[i] = formatODataProperty(s.by)
+			if s.desc {
+				keys[i] += " desc"
+			}
+		}
+		values.Set("$orderby", strings.Join(keys, ","))
+	}
+
+	if r.limit != "" && r.limitInt != InfiniteLimit {
+		values.Set("$top", r.limit)
+	}
+	if r.offset != "" {
+		values.Set("$skip", r.offset)
+	}
+
+	if len(r.selects) > 0 {
+		fields := make([]string, len(r.selects))
+		for i, f := range r.selects {
+			fields[i] = formatODataProperty(f)
+		}
+		values.Set("$select", strings.Join(fields, ","))
+	}
+
+	return values, nil
+}
+
+func formatODataFilter(n *RqlNode) (string, error) {
+	switch op := strings.ToLower(n.Op); op {
+	case "and", "or":
+		var exprs []string
+		for _, a := range n.Args {
+			c, ok := a.(*RqlNode)
+			if !ok {
+				return "", &ArgumentError{Op: n.Op, Arg: a, Reason: "must be an operator"}
+			}
+			s, err := formatODataFilter(c)
+			if err != nil {
+				return "", err
+			}
+			exprs = append(exprs, s)
+		}
+		return "(" + strings.Join(exprs, " "+op+" ") + ")", nil
+	case "not":
+		if len(n.Args) != 1 {
+			return "", &ArgumentError{Op: n.Op, Reason: "requires 1 argument"}
+		}
+		c, ok := n.Args[0].(*RqlNode)
+		if !ok {
+			return "", &ArgumentError{Op: n.Op, Arg: n.Args[0], Reason: "must be an operator"}
+		}
+		s, err := formatODataFilter(c)
+		if err != nil {
+			return "", err
+		}
+		return "not (" + s + ")", nil
+	case "eq", "ne", "gt", "ge", "lt", "le":
+		field, value, err := odataFieldValue(n)
+		if err != nil {
+			return "", err
+		}
+		return field + " " + op + " " + formatODataValue(value), nil
+	case "like":
+		field, value, err := odataFieldValue(n)
+		if err != nil {
+			return "", err
+		}
+		return formatODataLike(n, field, value)
+	case "in", "out":
+		if len(n.Args) < 2 {
+			return "", &ArgumentError{Op: n.Op, Reason: "requires at least 2 arguments"}
+		}
+		field, ok := n.Args[0].(string)
+		if !ok || !IsValidField(field) {
+			return "", &ArgumentError{Op: n.Op, Arg: n.Args[0], Reason: "must be a field name"}
+		}
+		list := make([]string, len(n.Args)-1)
+		for i, a := range n.Args[1:] {
+			if _, ok := a.(*RqlNode); ok {
+				return "", &ArgumentError{Op: n.Op, Arg: a, Reason: "must be a value"}
+			}
+			list[i] = formatODataValue(a)
+		}
+		s := formatODataProperty(field) + " in (" + strings.Join(list, ",") + ")"
+		if op == "out" {
+			s = "not (" + s + ")"
+		}
+		return s, nil
+	}
+	return "", fmt.Errorf("No OData equivalent for op : '%s'", n.Op)
+}
+
+// odataFieldValue returns the field and the value of a comparison
+func odataFieldValue(n *RqlNode) (string, interface{}, error) {
+	if len(n.Args) != 2 {
+		return "", nil, &ArgumentError{Op: n.Op, Reason: "requires 2 arguments"}
+	}
+	field, ok := n.Args[0].(string)
+	if !ok || !IsValidField(field) {
+		return "", nil, &ArgumentError{Op: n.Op, Arg: n.Args[0], Reason: "must be a field name"}
+	}
+	if _, ok := n.Args[1].(*RqlNode); ok {
+		return "", nil, &ArgumentError{Op: n.Op, Arg: n.Args[1], Reason: "must be a value"}
+	}
+	return formatODataProperty(field), n.Args[1], nil
+}
+
+// formatODataLike returns the string function matching the like pattern
+func formatODataLike(n *RqlNode, field string, value interface{}) (string, error) {
+	var pattern string
+	switch v := value.(type) {
+	case string:
+		pattern = v
+	case StringLiteral:
+		pattern = string(v)
+	}
+
+	var function string
+	inner := pattern
+	switch {
+	case len(pattern) > 1 && strings.HasPrefix(pattern, "*") && strings.HasSuffix(pattern, "*"):
+		function, inner = "contains", pattern[1:len(pattern)-1]
+	case strings.HasSuffix(pattern, "*"):
+		function, inner = "startswith", pattern[:len(pattern)-1]
+	case strings.HasPrefix(pattern, "*"):
+		function, inner = "endswith", pattern[1:]
+	}
+	if function == "" || strings.Contains(inner, "*") {
+		return "", &ArgumentError{Op: n.Op, Arg: value, Reason: "has no OData equivalent"}
+	}
+
+	return function + "(" + field + "," + formatODataString(inner) + ")", nil
+}
+
+func formatODataValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		if v == "null" || v == "true" || v == "false" {
+			return v
+		}
+		// The numbers with leading zeros are strings for the translators but
+		// are still written unquoted so they are read back unchanged
+		if odataNumber.MatchString(v) || odataDateTime.MatchString(v) {
+			return v
+		}
+		return formatODataString(v)
+	case StringLiteral:
+		return formatODataString(string(v))
+	}
+	return formatODataString(fmt.Sprint(value))
+}
+
+func formatODataString(s string) string {
+	return `'` + strings.Replace(s, `'`, `''`, -1) + `'`
+}
+
+func formatODataProperty(field string) string {
+	return strings.Replace(field, ".", "/", -1)
+}
@@ -0,0 +1,177 @@
+package rqlParser
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type ODataTest struct {
+	Name           string     // Name of the test
+	Query          string     // Input OData query string
+	RQL            string     // Equivalent RQL query producing the same root node
+	SQL            string     // Expected Output SQL
+	OData          url.Values // Expected output of FormatOData (the round trip is always checked)
+	WantParseError bool       // Test should raise an error when parsing the OData query
+}
+
+func (test *ODataTest) Run(t *testing.T) {
+	values, err := url.ParseQuery(test.Query)
+	if err != nil {
+		t.Fatalf("(%s) Invalid query string : %v", test.Name, err)
+	}
+
+	root, err := NewParser().ParseOData(values)
+	if test.WantParseError != (err != nil) {
+		t.Fatalf("(%s) Expecting error :%v\nGot error : %v", test.Name, test.WantParseError, err)
+	}
+	if err != nil {
+		return
+	}
+
+	if test.RQL != "" {
+		rqlRoot, err := NewParser().Parse(strings.NewReader(test.RQL))
+		if err != nil {
+			t.Fatalf("(%s) Unexpected RQL parse error : %v", test.Name, err)
+		}
+		if !reflect.DeepEqual(root, rqlRoot) {
+			t.Fatalf("(%s) OData root doesn’t match the RQL one %+v vs %+v", test.Name, root, rqlRoot)
+		}
+	}
+
+	s, err := NewSqlTranslator(root).Sql()
+	if err != nil {
+		t.Fatalf("(%s) Unexpected translator error : %v", test.Name, err)
+	}
+	if s != test.SQL {
+		t.Fatalf("(%s) Translated SQL doesn’t match the expected one %s vs %s", test.Name, s, test.SQL)
+	}
+
+	odata, err := FormatOData(root)
+	if err != nil {
+		t.Fatalf("(%s) Unexpected format error : %v", test.Name, err)
+	}
+	if test.OData != nil && !reflect.DeepEqual(odata, test.OData) {
+		t.Fatalf("(%s) Formatted OData doesn’t match the expected one %v vs %v", test.Name, odata, test.OData)
+	}
+	roundTrip, err := NewParser().ParseOData(odata)
+	if err != nil {
+		t.Fatalf("(%s) Unexpected error parsing the formatted OData %v : %v", test.Name, odata, err)
+	}
+	if !reflect.DeepEqual(root, roundTrip) {
+		t.Fatalf("(%s) Round trip doesn’t match %+v vs %+v", test.Name, roundTrip, root)
+	}
+}
+
+var odataTests = []ODataTest{
+	{
+		Name:  `All the system query options`,
+		Query: `$filter=Price gt 10 and (Status eq 'open' or Owner eq 'me')&$orderby=Date desc,Name&$top=20&$skip=40&$select=Id,Name`,
+		RQL:   `gt(Price,10)&(eq(Status,"open")|eq(Owner,"me"))&sort(-Date,+Name)&limit(20,40)&select(Id,Name)`,
+		SQL:   `WHERE ((Price > 10) AND ((Status = 'open') OR (Owner = 'me'))) ORDER BY Date DESC, Name LIMIT 20 OFFSET 40`,
+		OData: url.Values{
+			"$filter":  {`(Price gt 10 and (Status eq 'open' or Owner eq 'me'))`},
+			"$orderby": {`Date desc,Name`},
+			"$top":     {`20`},
+			"$skip":    {`40`},
+			"$select":  {`Id,Name`},
+		},
+	},
+	{
+		Name:  `String functions`,
+		Query: `$filter=contains(Name,'ab') or startswith(Name,'O''Neil') or not endswith(Name,'z')`,
+		RQL:   `like(Name,"*ab*")|like(Name,"O'Neil*")|not(like(Name,"*z"))`,
+		SQL:   `WHERE ((Name LIKE '%ab%') OR (Name LIKE 'O''Neil%') OR NOT((Name LIKE '%z')))`,
+		OData: url.Values{"$filter": {`(contains(Name,'ab') or startswith(Name,'O''Neil') or not (endswith(Name,'z')))`}},
+	},
+	{
+		Name:  `Comparison operators, literals and property paths`,
+		Query: `$filter=a ne null and b ge 1.5 and c lt -2 and d le true and Address/City in ('Paris','Lyon')`,
+		RQL:   `ne(a,null)&ge(b,1.5)&lt(c,-2)&le(d,true)&in(Address.City,"Paris","Lyon")`,
+		SQL:   `WHERE ((a IS NOT NULL) AND (b >= '1.5') AND (c < -2) AND (d <= 'true') AND (Address.City IN ('Paris', 'Lyon')))`,
+	},
+	{
+		Name:  `Date and DateTimeOffset literals`,
+		Query: `$filter=Created gt 2024-01-01T10:00:00Z and Updated le 2024-01-01T10:00:00.5%2B01:00 and Day eq 2024-01-01`,
+		RQL:   `gt(Created,2024-01-01T10%3A00%3A00Z)&le(Updated,2024-01-01T10%3A00%3A00.5%2B01%3A00)&eq(Day,2024-01-01)`,
+		SQL:   `WHERE ((Created > '2024-01-01T10:00:00Z') AND (Updated <= '2024-01-01T10:00:00.5+01:00') AND (Day = '2024-01-01'))`,
+	},
+	{
+		Name:           `Invalid DateTimeOffset literal`,
+		Query:          `$filter=Created gt 2024-01-01T10:00:x`,
+		WantParseError: true,
+	},
+	{
+		Name:  `Values with leading zeros`,
+		Query: `$filter=Zip eq 01234 and Code eq '007'`,
+		RQL:   `eq(Zip,01234)&eq(Code,"007")`,
+		SQL:   `WHERE ((Zip = 01234) AND (Code = '007'))`,
+		OData: url.Values{`$filter`: {`(Zip eq 01234 and Code eq '007')`}},
+	},
+	{
+		Name:  `Skip without top`,
+		Query: `$skip=10&$orderby=Address/City asc`,
+		SQL:   ` ORDER BY Address.City OFFSET 10`,
+		OData: url.Values{"$orderby": {`Address/City`}, "$skip": {`10`}},
+	},
+	{
+		Name:  `Not as a property name`,
+		Query: `$filter=not eq 1`,
+		RQL:   `eq(not,1)`,
+		SQL:   `WHERE (not = 1)`,
+	},
+	{
+		Name:           `Unsupported function`,
+		Query:          `$filter=length(Name) eq 3`,
+		WantParseError: true,
+	},
+	{
+		Name:           `Wildcard in a string function`,
+		Query:          `$filter=contains(Name,'a*b')`,
+		WantParseError: true,
+	},
+	{
+		Name:           `Missing comparison operator`,
+		Query:          `$filter=Name 'x'`,
+		WantParseError: true,
+	},
+	{
+		Name:           `Invalid direction`,
+		Query:          `$orderby=Name up`,
+		WantParseError: true,
+	},
+	{
+		Name:           `Invalid top`,
+		Query:          `$top=-1`,
+		WantParseError: true,
+	},
+}
+
+func TestOData(t *testing.T) {
+	for _, test := range odataTests {
+		test.Run(t)
+	}
+}
+
+func TestFormatODataErrors(t *testing.T) {
+	for _, rql := range []string{`like(Name,a*b)`, `match(Name,a)`} {
+		root, err := NewParser().Parse(strings.NewReader(rql))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = FormatOData(root); err == nil {
+			t.Fatalf("Expecting an error formatting %s", rql)
+		}
+	}
+}
+
+func TestODataDecodedValues(t *testing.T) {
+	root, err := NewParser().ParseOData(url.Values{`$filter`: {`Code eq a+b%25`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := root.Node.Args[1]; v != `a+b%25` {
+		t.Fatalf("Decoded value must not be unescaped again, got %v", v)
+	}
+}
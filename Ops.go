@@ -0,0 +1,200 @@
+package rqlParser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ArgKind is the set of the accepted kinds of an operator argument
+type ArgKind int
+
+const (
+	FieldArg ArgKind = 1 << iota // Valid field name
+	ValueArg                     // Any value
+	NodeArg                      // Nested operator
+	ListArg                      // List of values, only as the last kind of an OpSpec
+
+	AnyArg = FieldArg | ValueArg | NodeArg
+)
+
+func (k ArgKind) String() string {
+	var kinds []string
+	if k&FieldArg != 0 {
+		kinds = append(kinds, "field")
+	}
+	if k&ValueArg != 0 {
+		kinds = append(kinds, "value")
+	}
+	if k&NodeArg != 0 {
+		kinds = append(kinds, "operator")
+	}
+	if k&ListArg != 0 {
+		kinds = append(kinds, "list of values")
+	}
+	return strings.Join(kinds, " or ")
+}
+
+// accepts reports whether the argument matches the kind. The field names
+// syntax is checked by the translators as it depends on the target language.
+func (k ArgKind) accepts(arg interface{}) bool {
+	switch arg.(type) {
+	case *RqlNode:
+		return k&NodeArg != 0
+	case string:
+		return k&(FieldArg|ValueArg|ListArg) != 0
+	case StringLiteral:
+		return k&(ValueArg|ListArg) != 0
+	}
+	return false
+}
+
+// OpSpec declares the arguments accepted by an operator
+type OpSpec struct {
+	Op      string
+	MinArgs int
+	MaxArgs int       // -1 for no maximum
+	Kinds   []ArgKind // Kind of each argument, the last kind applies to the remaining arguments
+}
+
+// OpSpecs is a registry of OpSpec shared by the parser and the translators to
+// validate the operators arguments. Operators without OpSpec are not validated.
+type OpSpecs struct {
+	specs map[string]OpSpec
+}
+
+// NewOpSpecs returns the registry of the operators supported by default
+func NewOpSpecs() *OpSpecs {
+	o := &OpSpecs{specs: map[string]OpSpec{}}
+
+	o.Set(OpSpec{Op: "AND", MinArgs: 1, MaxArgs: -1, Kinds: []ArgKind{NodeArg | FieldArg}})
+	o.Set(OpSpec{Op: "OR", MinArgs: 1, MaxArgs: -1, Kinds: []ArgKind{NodeArg | FieldArg}})
+	o.Set(OpSpec{Op: "NOT", MinArgs: 1, MaxArgs: 1, Kinds: []ArgKind{NodeArg | FieldArg}})
+
+	for _, op := range []string{"EQ", "NE", "LIKE", "MATCH", "GT", "LT", "GE", "LE"} {
+		o.Set(OpSpec{Op: op, MinArgs: 2, MaxArgs: 2, Kinds: []ArgKind{FieldArg, ValueArg}})
+	}
+
+	o.Set(OpSpec{Op: "IN", MinArgs: 2, MaxArgs: -1, Kinds: []ArgKind{FieldArg, ListArg}})
+	o.Set(OpSpec{Op: "OUT", MinArgs: 2, MaxArgs: -1, Kinds: []ArgKind{FieldArg, ListArg}})
+
+	o.Set(OpSpec{Op: "LIMIT", MinArgs: 1, MaxArgs: 2, Kinds: []ArgKind{ValueArg}})
+	o.Set(OpSpec{Op: "SORT", MinArgs: 1, MaxArgs: -1, Kinds: []ArgKind{ValueArg}})
+	o.Set(OpSpec{Op: "SELECT", MinArgs: 1, MaxArgs: -1, Kinds: []ArgKind{FieldArg}})
+	o.Set(OpSpec{Op: "AFTER", MinArgs: 1, MaxArgs: 1, Kinds: []ArgKind{ValueArg}})
+	o.Set(OpSpec{Op: "BEFORE", MinArgs: 1, MaxArgs: 1, Kinds: []ArgKind{ValueArg}})
+
+	return o
+}
+
+// Set declares the OpSpec of an operator, it does nothing on a nil registry
+func (o *OpSpecs) Set(spec OpSpec) {
+	if o == nil {
+		return
+	}
+	o.specs[strings.ToUpper(spec.Op)] = spec
+}
+
+// Delete removes the OpSpec of an operator, it does nothing on a nil registry
+func (o *OpSpecs) Delete(op string) {
+	if o == nil {
+		return
+	}
+	delete(o.specs, strings.ToUpper(op))
+}
+
+// Get returns the OpSpec of an operator, a nil registry has no OpSpec
+func (o *OpSpecs) Get(op string) (spec OpSpec, ok bool) {
+	if o == nil {
+		return OpSpec{}, false
+	}
+	spec, ok = o.specs[strings.ToUpper(op)]
+	return
+}
+
+// Clone returns a copy of the registry, nil for a nil registry
+func (o *OpSpecs) Clone() *OpSpecs {
+	if o == nil {
+		return nil
+	}
+	c := &OpSpecs{specs: make(map[string]OpSpec, len(o.specs))}
+	for op, spec := range o.specs {
+		c.specs[op] = spec
+	}
+	return c
+}
+
+// Validate checks the arguments of the node and its children against their
+// OpSpec. A nil registry validates nothing.
+func (o *OpSpecs) Validate(n *RqlNode) error {
+	if o == nil || n == nil {
+		return nil
+	}
+
+	if spec, ok := o.Get(n.Op); ok {
+		if err := spec.validate(n); err != nil {
+			return err
+		}
+	}
+
+	for _, a := range n.Args {
+		if c, ok := a.(*RqlNode); ok {
+			if err := o.Validate(c); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (spec OpSpec) validate(n *RqlNode) error {
+	if len(n.Args) < spec.MinArgs || (spec.MaxArgs >= 0 && len(n.Args) > spec.MaxArgs) {
+		return &ArgumentError{Op: n.Op, Reason: "requires " + spec.arity()}
+	}
+
+	if len(spec.Kinds) == 0 {
+		return nil
+	}
+
+	for i, a := range n.Args {
+		kind := spec.kind(i)
+		if !kind.accepts(a) {
+			return &ArgumentError{Op: n.Op, Arg: a, Reason: "must be a " + kind.String()}
+		}
+	}
+
+	return nil
+}
+
+// kind returns the kind of the argument i, 0 when the OpSpec has no kinds
+func (spec OpSpec) kind(i int) ArgKind {
+	if len(spec.Kinds) == 0 {
+		return 0
+	}
+	if i < len(spec.Kinds) {
+		return spec.Kinds[i]
+	}
+	return spec.Kinds[len(spec.Kinds)-1]
+}
+
+// isField reports whether the argument i can only be a field name
+func (spec OpSpec) isField(i int) bool {
+	kind := spec.kind(i)
+	return kind&FieldArg != 0 && kind&(ValueArg|ListArg) == 0
+}
+
+func (spec OpSpec) arity() string {
+	plural := func(n int) string {
+		if n > 1 {
+			return strconv.Itoa(n) + " arguments"
+		}
+		return strconv.Itoa(n) + " argument"
+	}
+	switch {
+	case spec.MaxArgs < 0:
+		return "at least " + plural(spec.MinArgs)
+	case spec.MinArgs == spec.MaxArgs:
+		return plural(spec.MinArgs)
+	}
+	return strconv.Itoa(spec.MinArgs) + " to " + plural(spec.MaxArgs)
+}
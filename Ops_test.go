@@ -0,0 +1,101 @@
+package rqlParser
+
+import (
+	"strings"
+	"testing"
+)
+
+type OpSpecTest struct {
+	Name                string // Name of the test
+	RQL                 string // Input RQL query
+	WantParseError      bool   // Test should raise an error when parsing the RQL query
+	WantTranslatorError bool   // Test should raise an error when translating to SQL
+}
+
+func (test *OpSpecTest) Run(t *testing.T) {
+	rqlNode, err := NewParser().Parse(strings.NewReader(test.RQL))
+	if test.WantParseError != (err != nil) {
+		t.Fatalf("(%s) Expecting error :%v\nGot error : %v", test.Name, test.WantParseError, err)
+	}
+	if err != nil {
+		if _, ok := err.(*ArgumentError); !ok {
+			t.Fatalf("(%s) Expecting an ArgumentError, got %v", test.Name, err)
+		}
+		return
+	}
+
+	st := NewSqlTranslator(rqlNode)
+	st.SetOpFunc("between", st.GetOpFirstTranslatorFunc("BETWEEN", nil))
+	st.SetOpSpec(OpSpec{Op: "between", MinArgs: 3, MaxArgs: 3, Kinds: []ArgKind{FieldArg, ValueArg}})
+
+	_, err = st.Sql()
+	if test.WantTranslatorError != (err != nil) {
+		t.Fatalf("(%s) Expecting translator error :%v\nGot error : %v", test.Name, test.WantTranslatorError, err)
+	}
+}
+
+var opSpecTests = []OpSpecTest{
+	{Name: `Valid operators`, RQL: `and(gt(price,10),not(eq(foo,42)),or(disabled,visible))`},
+	{Name: `Missing argument`, RQL: `gt(price)`, WantParseError: true},
+	{Name: `Too many arguments`, RQL: `gt(price,10,20)`, WantParseError: true},
+	{Name: `Node instead of value`, RQL: `eq(foo,eq(bar,42))`, WantParseError: true},
+	{Name: `Node instead of field`, RQL: `lt(eq(bar,42),10)`, WantParseError: true},
+	{Name: `Empty or`, RQL: `or()`, WantParseError: true},
+	{Name: `Nested invalid operator`, RQL: `and(eq(foo,42),or(not(a,b),c))`, WantParseError: true},
+	{Name: `Custom operator`, RQL: `between(price,10,20)`},
+	{Name: `Custom operator with missing argument`, RQL: `between(price,10)`, WantTranslatorError: true},
+}
+
+func TestOpSpecs(t *testing.T) {
+	for _, test := range opSpecTests {
+		test.Run(t)
+	}
+}
+
+func TestSharedOpSpecs(t *testing.T) {
+	specs := NewOpSpecs()
+	specs.Set(OpSpec{Op: "between", MinArgs: 3, MaxArgs: 3, Kinds: []ArgKind{FieldArg, ValueArg}})
+
+	p := NewParser()
+	p.SetOpSpecs(specs)
+
+	if _, err := p.Parse(strings.NewReader(`between(price,10)`)); err == nil {
+		t.Fatalf("Expecting an error for a custom operator with a missing argument")
+	}
+}
+
+func TestNilOpSpecs(t *testing.T) {
+	root, err := NewParser().Parse(strings.NewReader(`gt(price,10)`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st := NewSqlTranslator(root)
+	st.SetOpSpecs(nil)
+	st.DeleteOpFunc("lt")
+	if _, err = st.Where(); err != nil {
+		t.Fatalf("Unexpected error without OpSpecs : %v", err)
+	}
+	st.SetOpSpec(OpSpec{Op: "gt", MinArgs: 3, MaxArgs: 3})
+	if _, err = st.Where(); err == nil {
+		t.Fatalf("Expecting an error for the OpSpec set after a nil registry")
+	}
+
+	p := NewParser()
+	p.SetOpSpecs(nil)
+	p.OpSpecs().Set(OpSpec{Op: "gt", MinArgs: 3, MaxArgs: 3})
+	if _, err = p.Parse(strings.NewReader(`gt(price)`)); err != nil {
+		t.Fatalf("Unexpected error without OpSpecs : %v", err)
+	}
+}
+
+func TestTranslatorOpSpecsCopy(t *testing.T) {
+	p := NewParser()
+	st := NewSqlTranslator(nil)
+	st.SetOpSpecs(p.OpSpecs())
+	st.DeleteOpFunc("gt")
+
+	if _, ok := p.OpSpecs().Get("gt"); !ok {
+		t.Fatalf("DeleteOpFunc must not change the registry of the parser")
+	}
+}
@@ -1,10 +1,12 @@
 package rqlParser
 
 import (
+	"bufio"
 	"fmt"
 	"io"
 	"strconv"
 	"strings"
+	"unicode"
 )
 
 var IsValueError error = fmt.Errorf("Bloc is a value")
@@ -19,6 +21,10 @@ func (tb TokenBloc) String() (s string) {
 	return
 }
 
+// StringLiteral is a quoted string argument (eg: "Hello, (world)"). Unlike
+// the unquoted values, it is never handled as a number, a boolean or null.
+type StringLiteral string
+
 type RqlNode struct {
 	Op   string
 	Args []interface{}
@@ -29,11 +35,51 @@ type Sort struct {
 	desc bool
 }
 
+// By returns the sorted field
+func (s Sort) By() string {
+	return s.by
+}
+
+// Desc reports whether the sort is descending
+func (s Sort) Desc() bool {
+	return s.desc
+}
+
+// String returns the RQL representation of the sort (eg: -price)
+func (s Sort) String() string {
+	if s.desc {
+		return "-" + s.by
+	}
+	return "+" + s.by
+}
+
+// InfiniteLimit is the LimitInt value of limit(Infinity)
+const InfiniteLimit = -1
+
 type RqlRootNode struct {
-	Node   *RqlNode
-	limit  string
-	offset string
-	sorts  []Sort
+	Node      *RqlNode
+	limit     string
+	offset    string
+	limitInt  int
+	offsetInt int
+	sorts     []Sort
+	selects   []string
+	after     string
+	before    string
+}
+
+// ArgumentError is returned when an operator argument is invalid
+type ArgumentError struct {
+	Op     string      // Operator name
+	Arg    interface{} // Invalid argument (nil when the number of arguments is invalid)
+	Reason string
+}
+
+func (e *ArgumentError) Error() string {
+	if e.Arg == nil {
+		return fmt.Sprintf("Invalid %s operator : %s", e.Op, e.Reason)
+	}
+	return fmt.Sprintf("Invalid %s operator argument (%v) : %s", e.Op, e.Arg, e.Reason)
 }
 
 func (r *RqlRootNode) Limit() string {
@@ -44,137 +90,367 @@ func (r *RqlRootNode) Offset() string {
 	return r.offset
 }
 
+// LimitInt returns the limit as an integer : 0 when there is no limit and
+// InfiniteLimit for limit(Infinity)
+func (r *RqlRootNode) LimitInt() int {
+	return r.limitInt
+}
+
 func (r *RqlRootNode) OffsetInt() int {
-	i, err := strconv.Atoi(r.offset)
-	if err != nil {
-		return 0
-	}
-	return i
+	return r.offsetInt
 }
 
 func (r *RqlRootNode) Sort() []Sort {
 	return r.sorts
 }
 
-func parseLimit(n *RqlNode, root *RqlRootNode) (isLimitOp bool) {
-	if n == nil {
-		return false
+// Select returns the fields of the select() operator
+func (r *RqlRootNode) Select() []string {
+	return r.selects
+}
+
+// After returns the cursor of the after() operator
+func (r *RqlRootNode) After() string {
+	return r.after
+}
+
+// Before returns the cursor of the before() operator
+func (r *RqlRootNode) Before() string {
+	return r.before
+}
+
+func parseLimit(n *RqlNode, root *RqlRootNode) (isLimitOp bool, err error) {
+	if n == nil || strings.ToUpper(n.Op) != "LIMIT" {
+		return false, nil
 	}
-	if strings.ToUpper(n.Op) == "LIMIT" {
-		root.limit = n.Args[0].(string)
-		if len(n.Args) > 1 {
-			root.offset = n.Args[1].(string)
+	if len(n.Args) == 0 || len(n.Args) > 2 {
+		return true, &ArgumentError{Op: n.Op, Reason: "requires 1 or 2 arguments"}
+	}
+
+	limit, ok := n.Args[0].(string)
+	if ok && strings.ToUpper(limit) == "INFINITY" {
+		root.limit, root.limitInt = limit, InfiniteLimit
+	} else if root.limitInt, err = parseNonNegativeInt(n.Op, n.Args[0]); err != nil {
+		return true, err
+	} else {
+		root.limit = strconv.Itoa(root.limitInt)
+	}
+
+	if len(n.Args) > 1 {
+		if root.offsetInt, err = parseNonNegativeInt(n.Op, n.Args[1]); err != nil {
+			return true, err
 		}
-		isLimitOp = true
+		root.offset = strconv.Itoa(root.offsetInt)
 	}
-	return
+
+	return true, nil
 }
 
-func parseSort(n *RqlNode, root *RqlRootNode) (isSortOp bool) {
-	if n == nil {
-		return false
-	}
-	if strings.ToUpper(n.Op) == "SORT" {
-		for _, s := range n.Args {
-			property := s.(string)
-			desc := false
-
-			if property[0] == '+' {
-				property = property[1:]
-			} else if property[0] == '-' {
-				desc = true
-				property = property[1:]
-			}
-			root.sorts = append(root.sorts, Sort{by: property, desc: desc})
+func parseNonNegativeInt(op string, arg interface{}) (int, error) {
+	s, ok := arg.(string)
+	if !ok {
+		return 0, &ArgumentError{Op: op, Arg: arg, Reason: "must be a non-negative integer"}
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil || i < 0 {
+		return 0, &ArgumentError{Op: op, Arg: arg, Reason: "must be a non-negative integer"}
+	}
+	return i, nil
+}
+
+func parseSort(n *RqlNode, root *RqlRootNode) (isSortOp bool, err error) {
+	if n == nil || strings.ToUpper(n.Op) != "SORT" {
+		return false, nil
+	}
+
+	for _, s := range n.Args {
+		property, ok := s.(string)
+		if !ok || property == "" {
+			return true, &ArgumentError{Op: n.Op, Arg: s, Reason: "must be a field name"}
 		}
+		desc := false
+
+		// The "+" prefix of an unescaped query (eg: sort(+price)) is decoded as a space
+		if property[0] == '+' || property[0] == ' ' {
+			property = property[1:]
+		} else if property[0] == '-' {
+			desc = true
+			property = property[1:]
+		}
+
+		if property == "" || !IsValidField(property) {
+			return true, &ArgumentError{Op: n.Op, Arg: s, Reason: "must be a field name"}
+		}
+		root.sorts = append(root.sorts, Sort{by: property, desc: desc})
+	}
+
+	return true, nil
+}
+
+func parseSelect(n *RqlNode, root *RqlRootNode) (isSelectOp bool, err error) {
+	if n == nil || strings.ToUpper(n.Op) != "SELECT" {
+		return false, nil
+	}
+	if len(n.Args) == 0 {
+		return true, &ArgumentError{Op: n.Op, Reason: "requires at least 1 argument"}
+	}
+
+	for _, a := range n.Args {
+		field, ok := a.(string)
+		if !ok || !IsValidField(field) {
+			return true, &ArgumentError{Op: n.Op, Arg: a, Reason: "must be a field name"}
+		}
+		root.selects = append(root.selects, field)
+	}
+
+	return true, nil
+}
+
+func parseCursor(n *RqlNode, root *RqlRootNode) (isCursorOp bool, err error) {
+	if n == nil {
+		return false, nil
+	}
+	op := strings.ToUpper(n.Op)
+	if op != "AFTER" && op != "BEFORE" {
+		return false, nil
+	}
+	if len(n.Args) != 1 {
+		return true, &ArgumentError{Op: n.Op, Reason: "requires 1 argument"}
+	}
+	cursor, ok := n.Args[0].(string)
+	if !ok || cursor == "" {
+		return true, &ArgumentError{Op: n.Op, Arg: n.Args[0], Reason: "must be a cursor"}
+	}
+	if root.after != "" || root.before != "" {
+		return true, &ArgumentError{Op: n.Op, Arg: cursor, Reason: "only one after or before operator is allowed"}
+	}
+	if op == "AFTER" {
+		root.after = cursor
+	} else {
+		root.before = cursor
+	}
+	return true, nil
+}
 
-		isSortOp = true
+func (r *RqlRootNode) parseSpecialOp(n *RqlNode) (isSpecialOp bool, err error) {
+	for _, parseFunc := range []func(*RqlNode, *RqlRootNode) (bool, error){parseLimit, parseSort, parseSelect, parseCursor} {
+		if isSpecialOp, err = parseFunc(n, r); isSpecialOp || err != nil {
+			return
+		}
 	}
 	return
 }
 
 func (r *RqlRootNode) ParseSpecialOps() (err error) {
-	if parseLimit(r.Node, r) || parseSort(r.Node, r) {
+	var isSpecialOp bool
+
+	if r.Node == nil {
+		return
+	}
+
+	if isSpecialOp, err = r.parseSpecialOp(r.Node); err != nil {
+		return
+	} else if isSpecialOp {
 		r.Node = nil
-	} else if r.Node != nil {
-		if strings.ToUpper(r.Node.Op) == "AND" {
-			limitIndex := -1
-			sortIndex := -1
-			for i, c := range r.Node.Args {
-				switch n := c.(type) {
-				case *RqlNode:
-					if parseLimit(n, r) {
-						limitIndex = i
-					} else if parseSort(n, r) {
-						sortIndex = i
-					}
-				}
-			}
-			if limitIndex >= 0 {
-				if sortIndex > limitIndex {
-					sortIndex = sortIndex - 1
-				}
-				if len(r.Node.Args) == 2 {
-					keepIndex := 0
-					if limitIndex == 0 {
-						keepIndex = 1
-					}
-					r.Node = r.Node.Args[keepIndex].(*RqlNode)
-				} else {
-					r.Node.Args = append(r.Node.Args[:limitIndex], r.Node.Args[limitIndex+1:]...)
-				}
-			}
-			if sortIndex >= 0 {
-				if len(r.Node.Args) == 2 {
-					keepIndex := 0
-					if sortIndex == 0 {
-						keepIndex = 1
-					}
-					r.Node = r.Node.Args[keepIndex].(*RqlNode)
-				} else {
-					r.Node.Args = append(r.Node.Args[:sortIndex], r.Node.Args[sortIndex+1:]...)
-				}
-			}
-			if len(r.Node.Args) == 0 {
-				r.Node = nil
+		return
+	}
+
+	if strings.ToUpper(r.Node.Op) != "AND" {
+		return
+	}
+
+	args := []interface{}{}
+	for _, c := range r.Node.Args {
+		if n, ok := c.(*RqlNode); ok {
+			if isSpecialOp, err = r.parseSpecialOp(n); err != nil {
+				return
+			} else if isSpecialOp {
+				continue
 			}
 		}
+		args = append(args, c)
+	}
+
+	if len(args) == 0 {
+		r.Node = nil
+	} else if n, ok := args[0].(*RqlNode); ok && len(args) == 1 {
+		r.Node = n
+	} else {
+		r.Node.Args = args
 	}
 
 	return
 }
 
+// DefaultMaxDepth is the default maximum nesting depth of a query
+const DefaultMaxDepth = 128
+
+// Syntax is the query language read by a Parser
+type Syntax int
+
+const (
+	RQLSyntax   Syntax = iota // Resource Query Language (default)
+	RSQLSyntax                // RSQL/FIQL (eg: name==foo;age=gt=30,status!=closed)
+	InfixSyntax               // Infix expressions (eg: price > 10 and status = "open" order by -date limit 20)
+)
+
 type Parser struct {
-	s *Scanner
+	reservedParams   map[string]bool
+	expressionParams map[string]bool
+	maxDepth         int
+	opSpecs          *OpSpecs
+	syntax           Syntax
+	fieldScripts     []*unicode.RangeTable
 }
 
 func NewParser() *Parser {
-	return &Parser{s: NewScanner()}
+	return &Parser{reservedParams: map[string]bool{}, maxDepth: DefaultMaxDepth, opSpecs: NewOpSpecs()}
 }
 
-func (p *Parser) Parse(r io.Reader) (root *RqlRootNode, err error) {
-	// defer func() {
-	// 	if r := recover(); r != nil {
-	// 		err, ok := r.(error)
-	// 		if !ok {
-	// 			err = fmt.Errorf("pkg: %v", r)
-	// 		}
-	// 		fmt.Println(err)
-	// 	}
-	// }()
-	var tokenStrings []TokenString
-	if tokenStrings, err = p.s.Scan(r); err != nil {
-		return nil, err
+// SetSyntax sets the query language read by the parser. Whatever the syntax,
+// the parser produces the same RqlNode tree so all the translators work.
+func (p *Parser) SetSyntax(syntax Syntax) {
+	p.syntax = syntax
+}
+
+// SetOpSpecs sets the registry used to validate the operators arguments after parsing
+func (p *Parser) SetOpSpecs(specs *OpSpecs) {
+	p.opSpecs = specs
+}
+
+// OpSpecs returns the registry used to validate the operators arguments, nil
+// when the validation is disabled
+func (p *Parser) OpSpecs() *OpSpecs {
+	return p.opSpecs
+}
+
+// SetReservedParams sets the query parameters which are not part of the RQL
+// query (eg: api_key) and are ignored by ParseQuery, ParseURLValues and ParseRequest
+func (p *Parser) SetReservedParams(params ...string) {
+	p.reservedParams = map[string]bool{}
+	for _, param := range params {
+		p.reservedParams[param] = true
+	}
+}
+
+// SetExpressionParams sets the parameters whose value is a RQL expression
+// (eg: filter=and(eq(a,1),eq(b,2))) in ParseURLValues
+func (p *Parser) SetExpressionParams(params ...string) {
+	p.expressionParams = map[string]bool{}
+	for _, param := range params {
+		p.expressionParams[param] = true
 	}
+}
+
+// SetMaxDepth sets the maximum nesting depth (parenthesis and operators) of the queries
+func (p *Parser) SetMaxDepth(depth int) {
+	p.maxDepth = depth
+}
+
+// SetFieldScripts restricts the scripts of the letters allowed in the field
+// names (eg: unicode.Latin), letters of any script are allowed by default. The
+// fields of the operators arguments are the ones declared as FieldArg by the
+// OpSpecs.
+func (p *Parser) SetFieldScripts(scripts ...*unicode.RangeTable) {
+	p.fieldScripts = scripts
+}
+
+// validate checks the parsed query against the OpSpecs and the field scripts
+func (p *Parser) validate(root *RqlRootNode) error {
+	if p == nil {
+		return nil
+	}
+	if err := p.opSpecs.Validate(root.Node); err != nil {
+		return err
+	}
+	if len(p.fieldScripts) == 0 {
+		return nil
+	}
+
+	for _, s := range root.sorts {
+		if !inScripts(s.by, p.fieldScripts) {
+			return &ArgumentError{Op: "sort", Arg: s.String(), Reason: "must be a field name"}
+		}
+	}
+	for _, field := range root.selects {
+		if !inScripts(field, p.fieldScripts) {
+			return &ArgumentError{Op: "select", Arg: field, Reason: "must be a field name"}
+		}
+	}
+	return p.validateFieldScripts(root.Node)
+}
+
+func (p *Parser) validateFieldScripts(n *RqlNode) error {
+	if n == nil {
+		return nil
+	}
+	spec, _ := p.opSpecs.Get(n.Op)
+
+	for i, a := range n.Args {
+		switch a := a.(type) {
+		case *RqlNode:
+			if err := p.validateFieldScripts(a); err != nil {
+				return err
+			}
+		case string:
+			if spec.isField(i) && !inScripts(a, p.fieldScripts) {
+				return &ArgumentError{Op: n.Op, Arg: a, Reason: "must be a field name"}
+			}
+		}
+	}
+	return nil
+}
+
+// inScripts reports whether the letters of s belong to one of the scripts.
+// Combining marks belong to the inherited script of the preceding letter.
+func inScripts(s string, scripts []*unicode.RangeTable) bool {
+	for _, ch := range s {
+		if isLetter(ch) && !unicode.In(ch, scripts...) {
+			return false
+		}
+		if isMark(ch) && !unicode.In(ch, scripts...) && !unicode.Is(unicode.Inherited, ch) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Parser) Parse(r io.Reader) (root *RqlRootNode, err error) {
+	return p.parse(r, false)
+}
+
+// parse reads the query, decoded is set when the query is already decoded
+// (eg: a value of url.Values) and must not be unescaped a second time
+func (p *Parser) parse(r io.Reader, decoded bool) (root *RqlRootNode, err error) {
+	// A new scanner is used for each call so a Parser can be shared between goroutines
+	ps := &parser{s: NewScanner(), maxDepth: p.maxDepth}
+	ps.s.decoded = decoded
+	ps.s.r = bufio.NewReader(r)
 
 	root = &RqlRootNode{}
 
-	root.Node, err = parse(tokenStrings)
-	if err != nil {
-		return nil, err
+	switch p.syntax {
+	case RSQLSyntax:
+		// RSQL has no sort, limit or cursor operators
+		if root.Node, err = ps.parseRSQLQuery(); err != nil {
+			return nil, err
+		}
+	case InfixSyntax:
+		if err = ps.parseInfixQuery(root); err != nil {
+			return nil, err
+		}
+	default:
+		if root.Node, err = ps.parseQuery(); err != nil {
+			return nil, err
+		}
+		if err = root.ParseSpecialOps(); err != nil {
+			return nil, err
+		}
 	}
 
-	root.ParseSpecialOps()
+	if err = p.validate(root); err != nil {
+		return nil, err
+	}
 
 	return
 }
@@ -189,236 +465,283 @@ func getTokenOp(t Token) string {
 	return ""
 }
 
-func parse(ts []TokenString) (node *RqlNode, err error) {
-	var childNode *RqlNode
-
-	childTs := [][]TokenString{}
-	node = &RqlNode{}
-
-	if len(ts) == 0 {
-		return nil, nil
-	}
+// parser is a single pass recursive descent parser reading the tokens from
+// the scanner one at a time. The grammar is :
+//
+//	query     = and EOF
+//	and       = or { ( "&" | "," ) or }      ("," separates the arguments in functions)
+//	or        = primary { ( "|" | ";" ) primary }
+//	primary   = "(" and ")" | IDENT "(" [ args ] ")" | IDENT "=" IDENT "=" value | IDENT "=" [ IDENT | STRING ] | IDENT | STRING
+//	args      = [ and ] { "," [ and ] }
+//	value     = "(" args ")" | STRING | { IDENT | "=" }
+type parser struct {
+	s        *Scanner
+	tok      TokenString
+	ahead    *TokenString // token read by peek
+	depth    int
+	maxDepth int
+}
 
-	if isParenthesisBloc(ts) && findClosingIndex(ts[1:]) == len(ts)-2 {
-		ts = ts[1 : len(ts)-1]
+// next reads the next token
+func (p *parser) next() error {
+	if p.ahead != nil {
+		p.tok, p.ahead = *p.ahead, nil
+		return nil
 	}
-
-	// __printTB("", ts)
-	node.Op, childTs = splitByBasisOp(ts)
-	// fmt.Println(len(childTs))
-	if node.Op == "" || len(childTs) == 1 {
-		return getBlocNode(ts)
+	t, lit := p.s.ScanToken()
+	if t == ILLEGAL {
+		return fmt.Errorf("Illegal Token : %s", lit)
 	}
+	p.tok = p.s.token(t, lit)
+	return nil
+}
 
-	for _, c := range childTs {
-		childNode, err = parse(c)
-		if err != nil {
-			if err == IsValueError {
-				node.Args = append(node.Args, c[0].s)
-			} else {
-				return nil, err
-			}
-		} else {
-			node.Args = append(node.Args, childNode)
+// peek returns the token following the current one without consuming it
+func (p *parser) peek() (TokenString, error) {
+	if p.ahead == nil {
+		t, lit := p.s.ScanToken()
+		if t == ILLEGAL {
+			return TokenString{}, fmt.Errorf("Illegal Token : %s", lit)
 		}
+		tok := p.s.token(t, lit)
+		p.ahead = &tok
 	}
+	return *p.ahead, nil
+}
 
-	return
+func (p *parser) unexpected() error {
+	if p.tok.t == EOF {
+		return fmt.Errorf("Unexpected end of query")
+	}
+	return fmt.Errorf("Unexpected token '%s'", p.tok.s)
 }
 
-func isTokenInSlice(tokens []Token, tok Token) bool {
-	for _, t := range tokens {
-		if t == tok {
-			return true
-		}
+func (p *parser) enter() error {
+	p.depth++
+	if p.maxDepth > 0 && p.depth > p.maxDepth {
+		return fmt.Errorf("Query exceeds the maximum depth of %d", p.maxDepth)
 	}
-	return false
+	return nil
 }
 
-func splitByBasisOp(tb []TokenString) (op string, tbs [][]TokenString) {
-	matchingToken := ILLEGAL
+func (p *parser) leave() {
+	p.depth--
+}
 
-	prof := 0
-	lastIndex := 0
+func (p *parser) parseQuery() (*RqlNode, error) {
+	if err := p.next(); err != nil {
+		return nil, err
+	}
 
-	basisTokenGroups := [][]Token{
-		[]Token{AMPERSAND, COMMA},
-		[]Token{PIPE, SEMI_COLON},
+	n, err := p.parseAnd(false)
+	if err != nil {
+		return nil, err
 	}
-	for _, bt := range basisTokenGroups {
-		btExtended := append(bt, ILLEGAL)
-		for i, ts := range tb {
-			if ts.t == OPENING_PARENTHESIS { // && lastIndex == i-1 {
-				prof++
-			} else if ts.t == CLOSING_PARENTHESIS && prof > 0 {
-				prof--
-			} else if prof == 0 {
-				if isTokenInSlice(bt, ts.t) && isTokenInSlice(btExtended, matchingToken) {
-					matchingToken = ts.t
-					tbs = append(tbs, tb[lastIndex:i])
-					lastIndex = i + 1
-				}
-			}
-		}
-		if lastIndex != 0 {
-			break
-		}
+	if p.tok.t != EOF {
+		return nil, p.unexpected()
 	}
 
-	tbs = append(tbs, tb[lastIndex:])
+	switch v := n.(type) {
+	case nil:
+		return nil, nil
+	case *RqlNode:
+		return v, nil
+	}
+	return nil, IsValueError
+}
 
-	op = getTokenOp(matchingToken)
+func isTerminator(t Token, inArgs bool) bool {
+	return t == EOF || t == CLOSING_PARENTHESIS || (inArgs && t == COMMA)
+}
 
-	return
+// parseAnd parses the expressions separated by "&" (and "," outside the
+// function arguments). Empty expressions are ignored.
+func (p *parser) parseAnd(inArgs bool) (interface{}, error) {
+	return p.parseList(inArgs, func(t Token) bool {
+		return t == AMPERSAND || (!inArgs && t == COMMA)
+	}, p.parseOr)
 }
 
-func getBlocNode(tb []TokenString) (*RqlNode, error) {
-	n := &RqlNode{}
+// parseOr parses the expressions separated by "|" or ";"
+func (p *parser) parseOr(inArgs bool) (interface{}, error) {
+	return p.parseList(inArgs, func(t Token) bool {
+		return t == PIPE || t == SEMI_COLON
+	}, func(inArgs bool) (interface{}, error) { return p.parsePrimary() })
+}
 
-	if isValue(tb) {
-		return nil, IsValueError
-	} else if isFuncStyleBloc(tb) {
-		var err error
-		n.Op = tb[0].s
-		tb = tb[2:]
-		ci := findClosingIndex(tb)
-		// fmt.Println(len(tb), tb[ci].s)
-		if len(tb) > ci+1 && tb[ci+1].t != CLOSING_PARENTHESIS && tb[ci+1].t != COMMA {
-			return nil, fmt.Errorf("Unrecognized func style bloc (missing comma?)")
-		}
-		// __printTB("", tb)
-		// __printTB("", tb[:ci])
-		n.Args, err = parseFuncArgs(tb[:ci])
-		if err != nil {
-			return nil, err
-		}
-	} else if isSimpleEqualBloc(tb) {
-		n.Op = "eq"
-		n.Args = []interface{}{tb[0].s, tb[2].s}
-
-	} else if isDoubleEqualBloc(tb) {
-		n.Op = tb[2].s
-		n.Args = []interface{}{tb[0].s}
-		tbLen := len(tb)
-		if tbLen == 4 {
-			n.Args = append(n.Args, ``)
-		} else if isParenthesisBloc(tb[4:]) && findClosingIndex(tb[5:]) == tbLen-6 {
-			args, err := parseFuncArgs(tb[5 : tbLen-1])
+func (p *parser) parseList(inArgs bool, isSeparator func(Token) bool, parseItem func(bool) (interface{}, error)) (interface{}, error) {
+	var (
+		args []interface{}
+		sep  Token = ILLEGAL
+	)
+
+	for {
+		if !isSeparator(p.tok.t) && !isTerminator(p.tok.t, inArgs) {
+			item, err := parseItem(inArgs)
 			if err != nil {
 				return nil, err
 			}
-			n.Args = append(n.Args, args...)
-		} else {
-			arg := ``
-			for _, a := range tb[4:] {
-				arg = arg + a.s
-			}
-			n.Args = append(n.Args, arg)
+			args = append(args, item)
 		}
 
-	} else {
-		return nil, fmt.Errorf("Unrecognized bloc : " + TokenBloc(tb).String())
+		if !isSeparator(p.tok.t) {
+			break
+		}
+		if sep == ILLEGAL {
+			sep = p.tok.t
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
 	}
 
-	return n, nil
-}
+	if len(args) == 0 {
+		return nil, nil
+	} else if len(args) == 1 {
+		return args[0], nil
+	}
 
-func isValue(tb []TokenString) bool {
-	return len(tb) == 1 && tb[0].t == IDENT
+	return &RqlNode{Op: getTokenOp(sep), Args: args}, nil
 }
 
-func isParenthesisBloc(tb []TokenString) bool {
-	return tb[0].t == OPENING_PARENTHESIS
-}
+func (p *parser) parsePrimary() (interface{}, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
 
-func isFuncStyleBloc(tb []TokenString) bool {
-	return (tb[0].t == IDENT) && (tb[1].t == OPENING_PARENTHESIS)
+	switch p.tok.t {
+	case OPENING_PARENTHESIS:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		n, err := p.parseAnd(false)
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.t != CLOSING_PARENTHESIS {
+			return nil, fmt.Errorf("Missing closing parenthesis")
+		}
+		return n, p.next()
+	case IDENT:
+		return p.parseIdent()
+	case STRING:
+		value := StringLiteral(p.tok.s)
+		return value, p.next()
+	}
+
+	return nil, p.unexpected()
 }
 
-func isSimpleEqualBloc(tb []TokenString) bool {
-	isSimple := (tb[0].t == IDENT && tb[1].t == EQUAL_SIGN)
-	if len(tb) > 3 {
-		isSimple = isSimple && tb[3].t != EQUAL_SIGN
+func (p *parser) parseIdent() (interface{}, error) {
+	ident := p.tok.s
+	if err := p.next(); err != nil {
+		return nil, err
 	}
 
-	return isSimple
-}
+	switch p.tok.t {
+	case OPENING_PARENTHESIS:
+		// Func style : op(arg1,arg2)
+		args, err := p.parseArgs()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.t == IDENT || p.tok.t == OPENING_PARENTHESIS {
+			return nil, fmt.Errorf("Unexpected token '%s' after %s (missing comma?)", p.tok.s, ident)
+		}
+		return &RqlNode{Op: ident, Args: args}, nil
+	case EQUAL_SIGN:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if p.tok.t == STRING {
+			// Simple equal with a string literal : field="value"
+			n := &RqlNode{Op: "eq", Args: []interface{}{ident, StringLiteral(p.tok.s)}}
+			return n, p.next()
+		}
+		if p.tok.t != IDENT {
+			// Simple equal without value : field=
+			return &RqlNode{Op: "eq", Args: []interface{}{ident, ``}}, nil
+		}
+		value := p.tok.s
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if p.tok.t != EQUAL_SIGN {
+			// Simple equal : field=value
+			return &RqlNode{Op: "eq", Args: []interface{}{ident, value}}, nil
+		}
+		// Double equal : field=op=value
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		args, err := p.parseDoubleEqualValue()
+		if err != nil {
+			return nil, err
+		}
+		return &RqlNode{Op: value, Args: append([]interface{}{ident}, args...)}, nil
+	}
 
-func isDoubleEqualBloc(tb []TokenString) bool {
-	return tb[0].t == IDENT && tb[1].t == EQUAL_SIGN && tb[2].t == IDENT && tb[3].t == EQUAL_SIGN
+	// Value
+	return ident, nil
 }
 
-func parseFuncArgs(tb []TokenString) (args []interface{}, err error) {
-	var argTokens [][]TokenString
+// parseArgs parses the function arguments, the current token being the opening parenthesis
+func (p *parser) parseArgs() (args []interface{}, err error) {
+	if err = p.next(); err != nil {
+		return nil, err
+	}
 
-	indexes := findAllTokenIndexes(tb, COMMA)
+	if p.tok.t == CLOSING_PARENTHESIS {
+		return []interface{}{}, p.next()
+	}
 
-	if len(indexes) == 0 {
-		argTokens = append(argTokens, tb)
-	} else {
-		lastIndex := 0
-		for _, i := range indexes {
-			argTokens = append(argTokens, tb[lastIndex:i])
-			lastIndex = i + 1
+	for {
+		var arg interface{}
+		if arg, err = p.parseAnd(true); err != nil {
+			return nil, err
 		}
-		argTokens = append(argTokens, tb[lastIndex:])
-	}
+		if arg == nil {
+			// Empty argument (eg: eq(foo,))
+			arg = ``
+		}
+		args = append(args, arg)
 
-	for _, ts := range argTokens {
-		n, err := parse(ts)
-		if err != nil {
-			if err == IsValueError {
-				args = append(args, ts[0].s)
-			} else {
-				return args, err
+		switch p.tok.t {
+		case COMMA:
+			if err = p.next(); err != nil {
+				return nil, err
 			}
-		} else {
-			args = append(args, n)
+		case CLOSING_PARENTHESIS:
+			return args, p.next()
+		default:
+			return nil, p.unexpected()
 		}
 	}
-
-	return
 }
 
-func findClosingIndex(tb []TokenString) int {
-	i := findTokenIndex(tb, CLOSING_PARENTHESIS)
-	return i
-}
-
-func findTokenIndex(tb []TokenString, token Token) int {
-	prof := 0
-	for i, ts := range tb {
-		if ts.t == OPENING_PARENTHESIS {
-			prof++
-		} else if ts.t == CLOSING_PARENTHESIS {
-			if prof == 0 && token == CLOSING_PARENTHESIS {
-				return i
-			}
-			prof--
-		} else if token == ts.t && prof == 0 {
-			return i
+// parseDoubleEqualValue parses the value of a field=op=value expression
+func (p *parser) parseDoubleEqualValue() ([]interface{}, error) {
+	if p.tok.t == OPENING_PARENTHESIS {
+		if err := p.enter(); err != nil {
+			return nil, err
 		}
+		defer p.leave()
+		return p.parseArgs()
 	}
-	return -1
-}
 
-func findAllTokenIndexes(tb []TokenString, token Token) (indexes []int) {
-	prof := 0
-	for i, ts := range tb {
-		if ts.t == OPENING_PARENTHESIS {
-			prof++
-		} else if ts.t == CLOSING_PARENTHESIS {
-			if prof == 0 && token == CLOSING_PARENTHESIS {
-				indexes = append(indexes, i)
-			}
-			prof--
-		} else if token == ts.t && prof == 0 {
-			indexes = append(indexes, i)
+	if p.tok.t == STRING {
+		args := []interface{}{StringLiteral(p.tok.s)}
+		return args, p.next()
+	}
+
+	value := ``
+	for p.tok.t == IDENT || p.tok.t == EQUAL_SIGN {
+		value += p.tok.s
+		if err := p.next(); err != nil {
+			return nil, err
 		}
 	}
-	return
-}
 
-func __printTB(s string, tb []TokenString) {
-	fmt.Printf("%s%s\n", s, TokenBloc(tb).String())
+	return []interface{}{value}, nil
 }
@@ -1,8 +1,11 @@
 package rqlParser
 
 import (
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
+	"unicode"
 )
 
 type Test struct {
@@ -131,6 +134,103 @@ var tests = []Test{
 		WantParseError:      false,
 		WantTranslatorError: true,
 	},
+	{
+		Name:                `Invalid RQL query (Non numeric limit)`,
+		RQL:                 `eq(foo,42)&limit(abc)`,
+		SQL:                 ``,
+		WantParseError:      true,
+		WantTranslatorError: false,
+	},
+	{
+		Name:                `Invalid RQL query (Negative offset)`,
+		RQL:                 `limit(10,-1)`,
+		SQL:                 ``,
+		WantParseError:      true,
+		WantTranslatorError: false,
+	},
+	{
+		Name:                `Invalid RQL query (Nested node in limit)`,
+		RQL:                 `limit(eq(foo,42))`,
+		SQL:                 ``,
+		WantParseError:      true,
+		WantTranslatorError: false,
+	},
+	{
+		Name:                `Invalid RQL query (Nested node in sort)`,
+		RQL:                 `sort(eq(foo,42))`,
+		SQL:                 ``,
+		WantParseError:      true,
+		WantTranslatorError: false,
+	},
+	{
+		Name:                `Invalid RQL query (Invalid sort field)`,
+		RQL:                 `sort(-price%3BDROP%20TABLE%20foo)`,
+		SQL:                 ``,
+		WantParseError:      true,
+		WantTranslatorError: false,
+	},
+	{
+		Name:                `Whitespaces between tokens`,
+		RQL:                 "and(eq(a, 1), eq(b,\t2))",
+		SQL:                 `WHERE ((a = 1) AND (b = 2))`,
+		WantParseError:      false,
+		WantTranslatorError: false,
+	},
+	{
+		Name: `Multi-line query with comments`,
+		RQL: `# Open tickets of the team
+and(
+    eq(status, open),  # Not closed
+    or(owner=me, owner=eq=team)
+)
+& sort(-date)
+`,
+		SQL:                 `WHERE ((status = 'open') AND ((owner = 'me') OR (owner = 'team'))) ORDER BY date DESC`,
+		WantParseError:      false,
+		WantTranslatorError: false,
+	},
+	{
+		Name:                `Quoted string literals`,
+		RQL:                 `and(eq(title,"Hello, (world)"),eq(quote,'it\'s'),eq(code,"42"),foo="null")`,
+		SQL:                 `WHERE ((title = 'Hello, (world)') AND (quote = 'it''s') AND (code = '42') AND (foo = 'null'))`,
+		WantParseError:      false,
+		WantTranslatorError: false,
+	},
+	{
+		Name:                `Quoted string literal with double equal operator`,
+		RQL:                 `title=like="50% (off)*"&name="a \"b\""`,
+		SQL:                 `WHERE ((title LIKE '50% (off)%') AND (name = 'a "b"'))`,
+		WantParseError:      false,
+		WantTranslatorError: false,
+	},
+	{
+		Name:                `Invalid RQL query (Unterminated string literal)`,
+		RQL:                 `eq(title,"Hello)`,
+		SQL:                 ``,
+		WantParseError:      true,
+		WantTranslatorError: false,
+	},
+	{
+		Name:                `Invalid RQL query (String literal as field)`,
+		RQL:                 `eq("title",foo)`,
+		SQL:                 ``,
+		WantParseError:      true,
+		WantTranslatorError: false,
+	},
+	{
+		Name:                `Unicode values and fields`,
+		RQL:                 `and(eq(city,Zürich),eq(名前,東京),straße=Café)`,
+		SQL:                 `WHERE ((city = 'Zürich') AND (名前 = '東京') AND (straße = 'Café'))`,
+		WantParseError:      false,
+		WantTranslatorError: false,
+	},
+	{
+		Name:                `Unicode combining marks`,
+		RQL:                 "eq(cafe\u0301,the\u0301)",
+		SQL:                 "WHERE (cafe\u0301 = 'the\u0301')",
+		WantParseError:      false,
+		WantTranslatorError: false,
+	},
 }
 
 func TestParser(t *testing.T) {
@@ -138,3 +238,112 @@ func TestParser(t *testing.T) {
 		test.Run(t)
 	}
 }
+
+func TestLimitInt(t *testing.T) {
+	p := NewParser()
+
+	root, err := p.Parse(strings.NewReader(`limit(010,20)`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.LimitInt() != 10 || root.Limit() != `10` || root.OffsetInt() != 20 {
+		t.Fatalf("Unexpected limit %d (%s) and offset %d", root.LimitInt(), root.Limit(), root.OffsetInt())
+	}
+
+	root, err = p.Parse(strings.NewReader(`limit(Infinity)`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.LimitInt() != InfiniteLimit {
+		t.Fatalf("Unexpected limit %d for Infinity", root.LimitInt())
+	}
+
+	_, err = p.Parse(strings.NewReader(`limit(abc)`))
+	if _, ok := err.(*ArgumentError); !ok {
+		t.Fatalf("Expecting an ArgumentError, got %v", err)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	p := NewParser()
+
+	root, err := p.Parse(strings.NewReader(`eq(foo,42)&select(id,name)`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(root.Select(), []string{"id", "name"}) || root.Node.Op != "eq" {
+		t.Fatalf("Unexpected select %v and node %v", root.Select(), root.Node)
+	}
+
+	_, err = p.Parse(strings.NewReader(`select(id,"name")`))
+	if _, ok := err.(*ArgumentError); !ok {
+		t.Fatalf("Expecting an ArgumentError, got %v", err)
+	}
+}
+
+func benchmarkQuery(clauses int) string {
+	var b strings.Builder
+	b.WriteString(`and(`)
+	for i := 0; i < clauses; i++ {
+		if i > 0 {
+			b.WriteString(`,`)
+		}
+		b.WriteString(`eq(field` + strconv.Itoa(i) + `,value%20` + strconv.Itoa(i) + `)`)
+	}
+	b.WriteString(`)&sort(+price)&limit(10)`)
+	return b.String()
+}
+
+func benchmarkParse(b *testing.B, clauses int) {
+	query := benchmarkQuery(clauses)
+	p := NewParser()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.Parse(strings.NewReader(query)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParse10(b *testing.B)   { benchmarkParse(b, 10) }
+func BenchmarkParse1k(b *testing.B)   { benchmarkParse(b, 1000) }
+func BenchmarkParse100k(b *testing.B) { benchmarkParse(b, 100000) }
+
+func TestMaxDepth(t *testing.T) {
+	p := NewParser()
+	query := strings.Repeat(`not(`, DefaultMaxDepth+1) + `foo` + strings.Repeat(`)`, DefaultMaxDepth+1)
+
+	if _, err := p.Parse(strings.NewReader(query)); err == nil {
+		t.Fatalf("Expecting an error for a query deeper than %d", DefaultMaxDepth)
+	}
+
+	p.SetMaxDepth(0)
+	if _, err := p.Parse(strings.NewReader(query)); err != nil {
+		t.Fatalf("Unexpected error without maximum depth : %v", err)
+	}
+}
+
+func TestFieldScripts(t *testing.T) {
+	p := NewParser()
+	p.SetFieldScripts(unicode.Latin)
+
+	for _, query := range []string{`eq(straße_2,名前)`, "in(cafe\u0301,1)&sort(-prix)&select(nom)"} {
+		if _, err := p.Parse(strings.NewReader(query)); err != nil {
+			t.Fatalf("Latin field names must be valid (%s) : %v", query, err)
+		}
+	}
+	for _, query := range []string{`eq(名前,1)`, `not(名前)`, `sort(-名前)`, `select(名前)`} {
+		if _, err := p.Parse(strings.NewReader(query)); err == nil {
+			t.Fatalf("Han field names must be invalid with the Latin script only (%s)", query)
+		}
+	}
+	if _, err := p.ParseCEL(`名前 == 1`); err == nil {
+		t.Fatalf("Han field names must be invalid with the Latin script only in CEL expressions")
+	}
+
+	if _, err := NewParser().Parse(strings.NewReader(`eq(名前,1)`)); err != nil {
+		t.Fatalf("Field names of any script must be valid by default : %v", err)
+	}
+}
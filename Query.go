@@ -0,0 +1,126 @@
+package rqlParser
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ParseQuery parses a raw (url encoded) query string where RQL expressions
+// can be mixed with plain parameters (eg: status=active&price=gt=10&sort(-date)).
+// The reserved parameters are ignored.
+func (p *Parser) ParseQuery(rawQuery string) (*RqlRootNode, error) {
+	var terms []string
+
+	for _, term := range splitQueryTerms(rawQuery) {
+		if term == "" {
+			continue
+		}
+		key, err := url.QueryUnescape(queryTermKey(term))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid query parameter %s : %s", term, err)
+		}
+		if p.reservedParams[key] {
+			continue
+		}
+		terms = append(terms, term)
+	}
+
+	return p.Parse(strings.NewReader(strings.Join(terms, "&")))
+}
+
+// ParseURLValues parses already decoded query parameters. The reserved
+// parameters are ignored. As url.Values are not ordered, the parameters
+// are combined by key alphabetical order.
+//
+// The keys without value (eg: sort(-date)) and the values of the empty key or
+// of the expression parameters (see SetExpressionParams) are parsed as RQL.
+// The other parameters are equalities (eg: title=Tom & Jerry is
+// eq(title,Tom & Jerry)).
+func (p *Parser) ParseURLValues(values url.Values) (*RqlRootNode, error) {
+	var (
+		keys    []string
+		terms   []string
+		filters []interface{}
+	)
+
+	for k := range values {
+		if !p.reservedParams[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		for _, v := range values[k] {
+			switch {
+			case v == "":
+				terms = append(terms, k)
+			case k == "" || p.expressionParams[k]:
+				terms = append(terms, v)
+			default:
+				if !IsValidField(k) {
+					return nil, fmt.Errorf("Invalid query parameter %s", k)
+				}
+				filters = append(filters, &RqlNode{Op: "eq", Args: []interface{}{k, v}})
+			}
+		}
+	}
+
+	// The values are already decoded
+	root, err := p.parse(strings.NewReader(strings.Join(terms, "&")), true)
+	if err != nil || len(filters) == 0 {
+		return root, err
+	}
+
+	if root.Node != nil {
+		filters = append([]interface{}{root.Node}, filters...)
+	}
+	if len(filters) == 1 {
+		root.Node = filters[0].(*RqlNode)
+	} else {
+		root.Node = &RqlNode{Op: "AND", Args: filters}
+	}
+
+	if err = p.validate(root); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// ParseRequest parses the raw query string of the request (see ParseQuery)
+func (p *Parser) ParseRequest(r *http.Request) (*RqlRootNode, error) {
+	return p.ParseQuery(r.URL.RawQuery)
+}
+
+// splitQueryTerms splits the query on the "&" which are not inside parenthesis
+func splitQueryTerms(query string) (terms []string) {
+	prof := 0
+	lastIndex := 0
+	for i, ch := range query {
+		switch ch {
+		case '(':
+			prof++
+		case ')':
+			if prof > 0 {
+				prof--
+			}
+		case '&':
+			if prof == 0 {
+				terms = append(terms, query[lastIndex:i])
+				lastIndex = i + 1
+			}
+		}
+	}
+	return append(terms, query[lastIndex:])
+}
+
+// queryTermKey returns the parameter name (or function name) of a query term
+func queryTermKey(term string) string {
+	if i := strings.IndexAny(term, "=("); i >= 0 {
+		return term[:i]
+	}
+	return term
+}
@@ -0,0 +1,125 @@
+package rqlParser
+
+import (
+	"net/url"
+	"testing"
+)
+
+type QueryTest struct {
+	Name           string     // Name of the test
+	Query          string     // Input raw query string
+	Values         url.Values // Input decoded values (used when Query is empty)
+	ReservedParams []string   // Parameters ignored by the parser
+	ExprParams     []string   // Parameters whose value is a RQL expression
+	SQL            string     // Expected Output SQL
+	WantParseError bool       // Test should raise an error when parsing the query
+}
+
+func (test *QueryTest) Run(t *testing.T) {
+	p := NewParser()
+	p.SetReservedParams(test.ReservedParams...)
+	p.SetExpressionParams(test.ExprParams...)
+
+	var (
+		rqlNode *RqlRootNode
+		err     error
+	)
+	if test.Values != nil {
+		rqlNode, err = p.ParseURLValues(test.Values)
+	} else {
+		rqlNode, err = p.ParseQuery(test.Query)
+	}
+	if test.WantParseError != (err != nil) {
+		t.Fatalf("(%s) Expecting error :%v\nGot error : %v", test.Name, test.WantParseError, err)
+	}
+	if err != nil {
+		return
+	}
+
+	s, err := NewSqlTranslator(rqlNode).Sql()
+	if err != nil {
+		t.Fatalf("(%s) Unexpected translator error : %v", test.Name, err)
+	}
+
+	if s != test.SQL {
+		t.Fatalf("(%s) Translated SQL doesn’t match the expected one %s vs %s", test.Name, s, test.SQL)
+	}
+}
+
+var queryTests = []QueryTest{
+	{
+		Name:           `Mixed plain and RQL parameters`,
+		Query:          `status=active&price=gt=10&api_key=secret&sort(-date)&limit(20)`,
+		ReservedParams: []string{`api_key`},
+		SQL:            `WHERE ((status = 'active') AND (price > 10)) ORDER BY date DESC LIMIT 20`,
+	},
+	{
+		Name:           `Reserved parameter inside parenthesis is kept`,
+		Query:          `or(eq(page,1),eq(page,2))&page=3`,
+		ReservedParams: []string{`page`},
+		SQL:            `WHERE ((page = 1) OR (page = 2))`,
+	},
+	{
+		Name:  `Plus sign sort prefix`,
+		Query: `sort(+price,-date)`,
+		SQL:   ` ORDER BY price, date DESC`,
+	},
+	{
+		Name:  `Plus sign decoded as a space in value`,
+		Query: `eq(foo,a+b)`,
+		SQL:   `WHERE (foo = 'a b')`,
+	},
+	{
+		Name:  `Encoded plus sign in value`,
+		Query: `eq(foo,a%2Bb)`,
+		SQL:   `WHERE (foo = 'a+b')`,
+	},
+	{
+		Name:           `Decoded values`,
+		Values:         url.Values{`status`: {`active`}, `price`: {`gt=10`}, `sort( price,-date)`: {``}, `api_key`: {`secret`}},
+		ReservedParams: []string{`api_key`},
+		SQL:            `WHERE ((price = 'gt=10') AND (status = 'active')) ORDER BY price, date DESC`,
+	},
+	{
+		Name:   `Decoded values with reserved characters`,
+		Values: url.Values{`title`: {`50% off`}},
+		SQL:    `WHERE (title = '50% off')`,
+	},
+	{
+		Name:   `Decoded values with RQL syntax characters`,
+		Values: url.Values{`title`: {`Tom & Jerry`}, `tags`: {`a,b`}, `name`: {`f(x)=1+1;|`}},
+		SQL:    `WHERE ((name = 'f(x)=1+1;|') AND (tags = 'a,b') AND (title = 'Tom & Jerry'))`,
+	},
+	{
+		Name:       `Decoded RQL expression value`,
+		Values:     url.Values{`filter`: {`and(eq(a,1),eq(b,"x y"))`}, `price`: {`gt=1+1`}},
+		ExprParams: []string{`filter`},
+		SQL:        `WHERE (((a = 1) AND (b = 'x y')) AND (price = 'gt=1+1'))`,
+	},
+	{
+		Name:   `Decoded values shaped like RQL`,
+		Values: url.Values{`title`: {`Re(quest)`}, `name`: {`a=b`}},
+		SQL:    `WHERE ((name = 'a=b') AND (title = 'Re(quest)'))`,
+	},
+	{
+		Name:   `Decoded RQL expression of the empty key`,
+		Values: url.Values{``: {`eq(a,b+c%)`}},
+		SQL:    `WHERE (a = 'b+c%')`,
+	},
+	{
+		Name:           `Decoded value of an invalid field`,
+		Values:         url.Values{`a b`: {`1`}},
+		WantParseError: true,
+	},
+	{
+		Name:           `Invalid query`,
+		Query:          `and(eq(foo,42)`,
+		WantParseError: true,
+	},
+}
+
+func TestQuery(t *testing.T) {
+	for _, test := range queryTests {
+		test.Run(t)
+	}
+}
@@ -0,0 +1,383 @@
+package rqlParser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// RediSearchFieldType is the type of a RediSearch index field
+type RediSearchFieldType int
+
+const (
+	RediSearchTag     RediSearchFieldType = iota // @field:{value}
+	RediSearchText                               // @field:value
+	RediSearchNumeric                            // @field:[min max]
+)
+
+type RediSearchTranslator struct {
+	rootNode   *RqlRootNode
+	opsDic     map[string]TranslatorOpFunc
+	opSpecs    *OpSpecs
+	fieldTypes map[string]RediSearchFieldType
+}
+
+func NewRediSearchTranslator(r *RqlRootNode) (rt *RediSearchTranslator) {
+	rt = &RediSearchTranslator{rootNode: r, opsDic: map[string]TranslatorOpFunc{}, opSpecs: NewOpSpecs(), fieldTypes: map[string]RediSearchFieldType{}}
+
+	rt.SetOpFunc("AND", rt.GetAndOrTranslatorOpFunc(" "))
+	rt.SetOpFunc("OR", rt.GetAndOrTranslatorOpFunc(" | "))
+	rt.SetOpFunc("NOT", rt.GetNotTranslatorOpFunc())
+
+	rt.SetOpFunc("EQ", rt.GetEqualityTranslatorOpFunc(false))
+	rt.SetOpFunc("NE", rt.GetEqualityTranslatorOpFunc(true))
+	rt.SetOpFunc("GT", rt.GetRangeTranslatorOpFunc("[(%s +inf]"))
+	rt.SetOpFunc("GE", rt.GetRangeTranslatorOpFunc("[%s +inf]"))
+	rt.SetOpFunc("LT", rt.GetRangeTranslatorOpFunc("[-inf (%s]"))
+	rt.SetOpFunc("LE", rt.GetRangeTranslatorOpFunc("[-inf %s]"))
+	rt.SetOpFunc("LIKE", rt.GetLikeTranslatorOpFunc())
+	rt.SetOpFunc("IN", rt.GetInTranslatorOpFunc(false))
+	rt.SetOpFunc("OUT", rt.GetInTranslatorOpFunc(true))
+
+	return
+}
+
+// SetFieldType declares the type of a field. The fields which are not
+// declared are numeric when compared to a number and tags otherwise.
+func (rt *RediSearchTranslator) SetFieldType(field string, t RediSearchFieldType) {
+	rt.fieldTypes[field] = t
+}
+
+func (rt *RediSearchTranslator) SetOpFunc(op string, f TranslatorOpFunc) {
+	rt.opsDic[strings.ToUpper(op)] = f
+}
+
+// DeleteOpFunc removes an operator, its OpSpec is removed too
+func (rt *RediSearchTranslator) DeleteOpFunc(op string) {
+	delete(rt.opsDic, strings.ToUpper(op))
+	rt.opSpecs.Delete(op)
+}
+
+// SetOpSpecs sets the registry used to validate the operators arguments, nil
+// disables the validation. The registry is copied so DeleteOpFunc doesn't
+// change the given one (eg: the registry of the Parser).
+func (rt *RediSearchTranslator) SetOpSpecs(specs *OpSpecs) {
+	rt.opSpecs = specs.Clone()
+}
+
+// Query returns the RediSearch query (eg: @status:{open} @price:[10 +inf]), * when the query is empty
+func (rt *RediSearchTranslator) Query() (string, error) {
+	if rt.rootNode == nil || rt.rootNode.Node == nil {
+		return "*", nil
+	}
+	if err := rt.opSpecs.Validate(rt.rootNode.Node); err != nil {
+		return "", err
+	}
+
+	// The top level intersection is not grouped
+	if n := rt.rootNode.Node; strings.ToUpper(n.Op) == "AND" {
+		exprs, err := rt.operands(n)
+		if err != nil {
+			return "", err
+		}
+		return strings.Join(exprs, " "), nil
+	}
+	return rt.where(rt.rootNode.Node)
+}
+
+// Args returns the FT.SEARCH arguments following the index name : the query,
+// SORTBY (RediSearch sorts on a single field) and LIMIT offset num.
+func (rt *RediSearchTranslator) Args() ([]string, error) {
+	q, err := rt.Query()
+	if err != nil {
+		return nil, err
+	}
+	args := []string{q}
+
+	if rt.rootNode == nil {
+		return args, nil
+	}
+
+	if sorts := rt.rootNode.Sort(); len(sorts) > 1 {
+		return nil, &ArgumentError{Op: "sort", Reason: "RediSearch sorts on a single field"}
+	} else if len(sorts) == 1 {
+		args = append(args, "SORTBY", sorts[0].By(), "ASC")
+		if sorts[0].Desc() {
+			args[len(args)-1] = "DESC"
+		}
+	}
+
+	if rt.rootNode.LimitInt() == InfiniteLimit {
+		return nil, &ArgumentError{Op: "limit", Arg: rt.rootNode.Limit(), Reason: "RediSearch requires a finite limit"}
+	}
+	if rt.rootNode.Limit() != "" || rt.rootNode.Offset() != "" {
+		// 10 is the default number of results of RediSearch
+		num := rt.rootNode.Limit()
+		if num == "" {
+			num = "10"
+		}
+		args = append(args, "LIMIT", strconv.Itoa(rt.rootNode.OffsetInt()), num)
+	}
+
+	return args, nil
+}
+
+func (rt *RediSearchTranslator) where(n *RqlNode) (string, error) {
+	f := rt.opsDic[strings.ToUpper(n.Op)]
+	if f == nil {
+		return "", fmt.Errorf("No TranslatorOpFunc for op : '%s'", n.Op)
+	}
+	return f(n)
+}
+
+// fieldType returns the declared type of the field or the type guessed from the value
+func (rt *RediSearchTranslator) fieldType(field string, value interface{}) RediSearchFieldType {
+	if t, ok := rt.fieldTypes[field]; ok {
+		return t
+	}
+	if s, ok := value.(string); ok && isNumber(s) {
+		return RediSearchNumeric
+	}
+	return RediSearchTag
+}
+
+// operands returns the translation of the children of n
+func (rt *RediSearchTranslator) operands(n *RqlNode) ([]string, error) {
+	if len(n.Args) == 0 {
+		return nil, &ArgumentError{Op: n.Op, Reason: "requires at least 1 argument"}
+	}
+
+	exprs := make([]string, len(n.Args))
+	for i, a := range n.Args {
+		c, ok := a.(*RqlNode)
+		if !ok {
+			return nil, &ArgumentError{Op: n.Op, Arg: a, Reason: "must be an operator"}
+		}
+		s, err := rt.where(c)
+		if err != nil {
+			return nil, err
+		}
+		exprs[i] = s
+	}
+	return exprs, nil
+}
+
+func (rt *RediSearchTranslator) GetAndOrTranslatorOpFunc(sep string) TranslatorOpFunc {
+	return TranslatorOpFunc(func(n *RqlNode) (string, error) {
+		exprs, err := rt.operands(n)
+		if err != nil {
+			return "", err
+		}
+		return "(" + strings.Join(exprs, sep) + ")", nil
+	})
+}
+
+func (rt *RediSearchTranslator) GetNotTranslatorOpFunc() TranslatorOpFunc {
+	return TranslatorOpFunc(func(n *RqlNode) (string, error) {
+		if len(n.Args) != 1 {
+			return "", &ArgumentError{Op: n.Op, Reason: "requires 1 argument"}
+		}
+		c, ok := n.Args[0].(*RqlNode)
+		if !ok {
+			return "", &ArgumentError{Op: n.Op, Arg: n.Args[0], Reason: "must be an operator"}
+		}
+		s, err := rt.where(c)
+		if err != nil {
+			return "", err
+		}
+		return "-" + s, nil
+	})
+}
+
+// GetEqualityTranslatorOpFunc returns a tag, text or numeric match. The
+// equality to null is translated to ismissing (the field must be indexed with INDEXMISSING).
+func (rt *RediSearchTranslator) GetEqualityTranslatorOpFunc(negated bool) TranslatorOpFunc {
+	return TranslatorOpFunc(func(n *RqlNode) (string, error) {
+		field, value, err := redisFieldValue(n)
+		if err != nil {
+			return "", err
+		}
+
+		s := "ismissing(" + field + ")"
+		if value != "null" {
+			if s, err = rt.match(n, field, value); err != nil {
+				return "", err
+			}
+		}
+
+		if negated {
+			s = "-" + s
+		}
+		return s, nil
+	})
+}
+
+// match returns the equality of the field and the value according to the field type
+func (rt *RediSearchTranslator) match(n *RqlNode, field string, value interface{}) (string, error) {
+	switch rt.fieldType(n.Args[0].(string), value) {
+	case RediSearchNumeric:
+		v, err := redisNumber(n, value)
+		if err != nil {
+			return "", err
+		}
+		return field + ":[" + v + " " + v + "]", nil
+	case RediSearchText:
+		return field + ":" + redisText(redisString(value)), nil
+	}
+	return field + ":{" + escapeRediSearch(redisString(value)) + "}", nil
+}
+
+// GetRangeTranslatorOpFunc returns a numeric range, format holds the bounds (eg: [(%s +inf])
+func (rt *RediSearchTranslator) GetRangeTranslatorOpFunc(format string) TranslatorOpFunc {
+	return TranslatorOpFunc(func(n *RqlNode) (string, error) {
+		field, value, err := redisFieldValue(n)
+		if err != nil {
+			return "", err
+		}
+		if t := rt.fieldType(n.Args[0].(string), value); t != RediSearchNumeric {
+			return "", &ArgumentError{Op: n.Op, Arg: n.Args[0], Reason: "must be a numeric field"}
+		}
+		v, err := redisNumber(n, value)
+		if err != nil {
+			return "", err
+		}
+		return field + ":" + fmt.Sprintf(format, v), nil
+	})
+}
+
+// GetLikeTranslatorOpFunc returns a prefix (abc*), suffix (*abc) or infix (*abc*) match
+func (rt *RediSearchTranslator) GetLikeTranslatorOpFunc() TranslatorOpFunc {
+	return TranslatorOpFunc(func(n *RqlNode) (string, error) {
+		field, value, err := redisFieldValue(n)
+		if err != nil {
+			return "", err
+		}
+
+		pattern := redisString(value)
+		inner := strings.TrimSuffix(strings.TrimPrefix(pattern, "*"), "*")
+		if inner == "" || strings.Contains(inner, "*") {
+			return "", &ArgumentError{Op: n.Op, Arg: value, Reason: "has no RediSearch equivalent"}
+		}
+		s := strings.Replace(pattern, inner, escapeRediSearch(inner), 1)
+
+		switch rt.fieldType(n.Args[0].(string), StringLiteral(pattern)) {
+		case RediSearchNumeric:
+			return "", &ArgumentError{Op: n.Op, Arg: n.Args[0], Reason: "must be a tag or text field"}
+		case RediSearchText:
+			if strings.IndexFunc(inner, unicode.IsSpace) >= 0 {
+				return "", &ArgumentError{Op: n.Op, Arg: value, Reason: "has no RediSearch equivalent"}
+			}
+			return field + ":" + s, nil
+		}
+		return field + ":{" + s + "}", nil
+	})
+}
+
+func (rt *RediSearchTranslator) GetInTranslatorOpFunc(negated bool) TranslatorOpFunc {
+	return TranslatorOpFunc(func(n *RqlNode) (string, error) {
+		if len(n.Args) < 2 {
+			return "", &ArgumentError{Op: n.Op, Reason: "requires at least 2 arguments"}
+		}
+		field, err := redisField(n.Args[0])
+		if err != nil {
+			return "", err
+		}
+
+		values := make([]string, len(n.Args)-1)
+		for i, a := range n.Args[1:] {
+			switch a.(type) {
+			case string, StringLiteral:
+			default:
+				return "", &ArgumentError{Op: n.Op, Arg: a, Reason: "must be a value"}
+			}
+			values[i] = escapeRediSearch(redisString(a))
+		}
+
+		var s string
+		switch rt.fieldType(n.Args[0].(string), n.Args[1]) {
+		case RediSearchNumeric:
+			for i, a := range n.Args[1:] {
+				v, err := redisNumber(n, a)
+				if err != nil {
+					return "", err
+				}
+				values[i] = field + ":[" + v + " " + v + "]"
+			}
+			s = "(" + strings.Join(values, " | ") + ")"
+		case RediSearchText:
+			for i, a := range n.Args[1:] {
+				values[i] = redisText(redisString(a))
+			}
+			s = field + ":(" + strings.Join(values, " | ") + ")"
+		default:
+			s = field + ":{" + strings.Join(values, " | ") + "}"
+		}
+
+		if negated {
+			s = "-" + s
+		}
+		return s, nil
+	})
+}
+
+// redisFieldValue returns the field (eg: @price) and the value of a comparison
+func redisFieldValue(n *RqlNode) (string, interface{}, error) {
+	if len(n.Args) != 2 {
+		return "", nil, &ArgumentError{Op: n.Op, Reason: "requires 2 arguments"}
+	}
+	field, err := redisField(n.Args[0])
+	if err != nil {
+		return "", nil, err
+	}
+	switch n.Args[1].(type) {
+	case string, StringLiteral:
+	default:
+		return "", nil, &ArgumentError{Op: n.Op, Arg: n.Args[1], Reason: "must be a value"}
+	}
+	return field, n.Args[1], nil
+}
+
+func redisField(arg interface{}) (string, error) {
+	field, ok := arg.(string)
+	if !ok || !IsValidField(field) {
+		return "", fmt.Errorf("Invalid field name : %v", arg)
+	}
+	return "@" + escapeRediSearch(field), nil
+}
+
+func redisString(value interface{}) string {
+	if s, ok := value.(StringLiteral); ok {
+		return string(s)
+	}
+	return value.(string)
+}
+
+// redisNumber returns the value if it is an unquoted number
+func redisNumber(n *RqlNode, value interface{}) (string, error) {
+	if s, ok := value.(string); ok && isNumber(s) {
+		return s, nil
+	}
+	return "", &ArgumentError{Op: n.Op, Arg: value, Reason: "must be a number"}
+}
+
+// redisText returns a text term, or an exact phrase (eg: "hello world") when
+// the value has several words
+func redisText(s string) string {
+	if strings.IndexFunc(s, unicode.IsSpace) < 0 {
+		return escapeRediSearch(s)
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// escapeRediSearch escapes the punctuation and the spaces with a backslash
+func escapeRediSearch(s string) string {
+	var buf strings.Builder
+	for _, ch := range s {
+		if ch != '_' && (unicode.IsPunct(ch) || unicode.IsSymbol(ch) || unicode.IsSpace(ch)) {
+			buf.WriteByte('\\')
+		}
+		buf.WriteRune(ch)
+	}
+	return buf.String()
+}
@@ -0,0 +1,106 @@
+package rqlParser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type RediSearchTest struct {
+	Name                string                         // Name of the test
+	RQL                 string                         // Input RQL query
+	FieldTypes          map[string]RediSearchFieldType // Declared field types
+	Args                []string                       // Expected FT.SEARCH arguments
+	WantTranslatorError bool                           // Test should raise an error when translating
+}
+
+func (test *RediSearchTest) Run(t *testing.T) {
+	root, err := NewParser().Parse(strings.NewReader(test.RQL))
+	if err != nil {
+		t.Fatalf("(%s) Unexpected parse error : %v", test.Name, err)
+	}
+
+	rt := NewRediSearchTranslator(root)
+	for field, fieldType := range test.FieldTypes {
+		rt.SetFieldType(field, fieldType)
+	}
+
+	args, err := rt.Args()
+	if test.WantTranslatorError != (err != nil) {
+		t.Fatalf("(%s) Expecting error :%v\nGot error : %v", test.Name, test.WantTranslatorError, err)
+	}
+	if err != nil {
+		return
+	}
+
+	if !reflect.DeepEqual(args, test.Args) {
+		t.Fatalf("(%s) Translated arguments don’t match the expected ones %q vs %q", test.Name, args, test.Args)
+	}
+}
+
+var rediSearchTests = []RediSearchTest{
+	{
+		Name: `Tags, ranges, sort and limit`,
+		RQL:  `eq(status,open)&ge(price,10)&sort(-price)&limit(20,40)`,
+		Args: []string{`@status:{open} @price:[10 +inf]`, `SORTBY`, `price`, `DESC`, `LIMIT`, `40`, `20`},
+	},
+	{
+		Name: `Unions, negations and exclusive ranges`,
+		RQL:  `or(gt(a,1),lt(a,-2.5),not(eq(b,x)),ne(c,5))`,
+		Args: []string{`(@a:[(1 +inf] | @a:[-inf (-2.5] | -@b:{x} | -@c:[5 5])`},
+	},
+	{
+		Name:       `Text fields and escaping`,
+		RQL:        `eq(title,"hello world")&eq(body,"say \"hi\"")&eq(word,"a-b")&eq(tag,"a-b,c")&eq(code,"42")&like(name,Chris*)&like(city,*ork)`,
+		FieldTypes: map[string]RediSearchFieldType{"title": RediSearchText, "body": RediSearchText, "word": RediSearchText, "city": RediSearchText},
+		Args:       []string{`@title:"hello world" @body:"say \"hi\"" @word:a\-b @tag:{a\-b\,c} @code:{42} @name:{Chris*} @city:*ork`},
+	},
+	{
+		Name: `Values with leading zeros`,
+		RQL:  `eq(zip,01234)&eq(n,0)`,
+		Args: []string{`@zip:{01234} @n:[0 0]`},
+	},
+	{
+		Name:       `Lists and null`,
+		RQL:        `in(tag,a,"b c")&out(n,1,2)&in(title,x,"y z")&eq(deleted,null)&ne(owner.id,null)&sort(+name)&limit(5)`,
+		FieldTypes: map[string]RediSearchFieldType{"title": RediSearchText},
+		Args:       []string{`@tag:{a | b\ c} -(@n:[1 1] | @n:[2 2]) @title:(x | "y z") ismissing(@deleted) -ismissing(@owner\.id)`, `SORTBY`, `name`, `ASC`, `LIMIT`, `0`, `5`},
+	},
+	{
+		Name: `Empty query`,
+		RQL:  ``,
+		Args: []string{`*`},
+	},
+	{
+		Name:                `Range on a tag`,
+		RQL:                 `gt(status,open)`,
+		WantTranslatorError: true,
+	},
+	{
+		Name:                `Several sort fields`,
+		RQL:                 `sort(+a,-b)`,
+		WantTranslatorError: true,
+	},
+	{
+		Name:                `Infinite limit`,
+		RQL:                 `limit(Infinity)`,
+		WantTranslatorError: true,
+	},
+	{
+		Name:                `Unsupported like pattern`,
+		RQL:                 `like(name,a*b)`,
+		WantTranslatorError: true,
+	},
+	{
+		Name:                `Like pattern of several words on a text field`,
+		RQL:                 `like(title,"hello wor*")`,
+		FieldTypes:          map[string]RediSearchFieldType{"title": RediSearchText},
+		WantTranslatorError: true,
+	},
+}
+
+func TestRediSearchTranslator(t *testing.T) {
+	for _, test := range rediSearchTests {
+		test.Run(t)
+	}
+}
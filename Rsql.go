@@ -0,0 +1,198 @@
+package rqlParser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// rsqlComparisonOps maps the RSQL symbolic comparison operators to the RQL operators
+var rsqlComparisonOps = map[Token]string{
+	EQUAL:            "eq",
+	NOT_EQUAL:        "ne",
+	LOWER:            "lt",
+	LOWER_OR_EQUAL:   "le",
+	GREATER:          "gt",
+	GREATER_OR_EQUAL: "ge",
+}
+
+// parseRSQLQuery parses a RSQL/FIQL query. The grammar is :
+//
+//	query      = or EOF
+//	or         = and { ( "," | "or" ) and }
+//	and        = constraint { ( ";" | "and" ) constraint }
+//	constraint = "(" or ")" | IDENT comparator arguments
+//	comparator = "==" | "!=" | "<" | "<=" | ">" | ">=" | "=" IDENT "="
+//	arguments  = "(" value { "," value } ")" | value
+//	value      = IDENT | STRING
+//
+// "==" and "!=" with an unquoted value containing a "*" are translated to like.
+func (p *parser) parseRSQLQuery() (*RqlNode, error) {
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if p.tok.t == EOF {
+		return nil, nil
+	}
+
+	n, err := p.parseRSQLOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.t != EOF {
+		return nil, p.unexpected()
+	}
+
+	return n, nil
+}
+
+func (p *parser) isRSQLKeyword(keyword string) bool {
+	return p.tok.t == IDENT && strings.ToLower(p.tok.s) == keyword
+}
+
+func (p *parser) parseRSQLOr() (*RqlNode, error) {
+	return p.parseRSQLList("OR", func() bool {
+		return p.tok.t == COMMA || p.isRSQLKeyword("or")
+	}, p.parseRSQLAnd)
+}
+
+func (p *parser) parseRSQLAnd() (*RqlNode, error) {
+	return p.parseRSQLList("AND", func() bool {
+		return p.tok.t == SEMI_COLON || p.isRSQLKeyword("and")
+	}, p.parseRSQLConstraint)
+}
+
+func (p *parser) parseRSQLList(op string, isSeparator func() bool, parseItem func() (*RqlNode, error)) (*RqlNode, error) {
+	var args []interface{}
+
+	for {
+		item, err := parseItem()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, item)
+
+		if !isSeparator() {
+			break
+		}
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(args) == 1 {
+		return args[0].(*RqlNode), nil
+	}
+
+	return &RqlNode{Op: op, Args: args}, nil
+}
+
+func (p *parser) parseRSQLConstraint() (*RqlNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	if p.tok.t == OPENING_PARENTHESIS {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		n, err := p.parseRSQLOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.t != CLOSING_PARENTHESIS {
+			return nil, fmt.Errorf("Missing closing parenthesis")
+		}
+		return n, p.next()
+	}
+
+	if p.tok.t != IDENT {
+		return nil, p.unexpected()
+	}
+	n := &RqlNode{Args: []interface{}{p.tok.s}}
+
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	if op, ok := rsqlComparisonOps[p.tok.t]; ok {
+		n.Op = op
+	} else if p.tok.t == EQUAL_SIGN {
+		// FIQL operator : =op=
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if p.tok.t != IDENT {
+			return nil, p.unexpected()
+		}
+		n.Op = p.tok.s
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if p.tok.t != EQUAL_SIGN {
+			return nil, fmt.Errorf("Invalid comparison operator =%s (missing '=')", n.Op)
+		}
+	} else {
+		return nil, fmt.Errorf("Missing comparison operator after %s", n.Args[0])
+	}
+
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	args, err := p.parseRSQLArguments()
+	if err != nil {
+		return nil, err
+	}
+	n.Args = append(n.Args, args...)
+
+	// Wildcard equality
+	if value, ok := n.Args[1].(string); ok && len(n.Args) == 2 && strings.Contains(value, "*") {
+		if n.Op == "eq" {
+			n.Op = "like"
+		} else if n.Op == "ne" {
+			n = &RqlNode{Op: "not", Args: []interface{}{&RqlNode{Op: "like", Args: n.Args}}}
+		}
+	}
+
+	return n, nil
+}
+
+func (p *parser) parseRSQLArguments() (args []interface{}, err error) {
+	if p.tok.t != OPENING_PARENTHESIS {
+		value, err := p.parseRSQLValue()
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{value}, nil
+	}
+
+	for {
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+		value, err := p.parseRSQLValue()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, value)
+
+		if p.tok.t == CLOSING_PARENTHESIS {
+			return args, p.next()
+		} else if p.tok.t != COMMA {
+			return nil, p.unexpected()
+		}
+	}
+}
+
+func (p *parser) parseRSQLValue() (value interface{}, err error) {
+	switch p.tok.t {
+	case IDENT:
+		value = p.tok.s
+	case STRING:
+		value = StringLiteral(p.tok.s)
+	default:
+		return nil, p.unexpected()
+	}
+	return value, p.next()
+}
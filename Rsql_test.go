@@ -0,0 +1,99 @@
+package rqlParser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type RSQLTest struct {
+	Name           string // Name of the test
+	RSQL           string // Input RSQL query
+	RQL            string // Equivalent RQL query producing the same tree
+	SQL            string // Expected Output SQL
+	WantParseError bool   // Test should raise an error when parsing the RSQL query
+}
+
+func (test *RSQLTest) Run(t *testing.T) {
+	p := NewParser()
+	p.SetSyntax(RSQLSyntax)
+
+	root, err := p.Parse(strings.NewReader(test.RSQL))
+	if test.WantParseError != (err != nil) {
+		t.Fatalf("(%s) Expecting error :%v\nGot error : %v", test.Name, test.WantParseError, err)
+	}
+	if err != nil {
+		return
+	}
+
+	if test.RQL != "" {
+		rqlRoot, err := NewParser().Parse(strings.NewReader(test.RQL))
+		if err != nil {
+			t.Fatalf("(%s) Unexpected RQL parse error : %v", test.Name, err)
+		}
+		if !reflect.DeepEqual(root.Node, rqlRoot.Node) {
+			t.Fatalf("(%s) RSQL tree doesn’t match the RQL one %v vs %v", test.Name, root.Node, rqlRoot.Node)
+		}
+	}
+
+	s, err := NewSqlTranslator(root).Sql()
+	if err != nil {
+		t.Fatalf("(%s) Unexpected translator error : %v", test.Name, err)
+	}
+	if s != test.SQL {
+		t.Fatalf("(%s) Translated SQL doesn’t match the expected one %s vs %s", test.Name, s, test.SQL)
+	}
+}
+
+var rsqlTests = []RSQLTest{
+	{
+		Name: `Precedence of and over or`,
+		RSQL: `name==foo;age=gt=30,status!=closed`,
+		RQL:  `OR(AND(eq(name,foo),gt(age,30)),ne(status,closed))`,
+		SQL:  `WHERE (((name = 'foo') AND (age > 30)) OR (status != 'closed'))`,
+	},
+	{
+		Name: `Symbolic comparison operators`,
+		RSQL: `a<1;b<=2;c>3;d>=4`,
+		RQL:  `AND(lt(a,1),le(b,2),gt(c,3),ge(d,4))`,
+		SQL:  `WHERE ((a < 1) AND (b <= 2) AND (c > 3) AND (d >= 4))`,
+	},
+	{
+		Name: `Keywords and grouping`,
+		RSQL: `genres=in=(sci-fi,action) and (director=='Christopher Nolan' or year=out=(2000,2001))`,
+		RQL:  `AND(in(genres,sci-fi,action),OR(eq(director,"Christopher Nolan"),out(year,2000,2001)))`,
+		SQL:  `WHERE ((genres IN ('sci-fi', 'action')) AND ((director = 'Christopher Nolan') OR (year NOT IN (2000, 2001))))`,
+	},
+	{
+		Name: `Wildcards`,
+		RSQL: `name==Chris*;title!=*draft*;code=="a*"`,
+		RQL:  `AND(like(name,Chris*),not(like(title,*draft*)),eq(code,"a*"))`,
+		SQL:  `WHERE ((name LIKE 'Chris%') AND NOT((title LIKE '%draft%')) AND (code = 'a*'))`,
+	},
+	{
+		Name: `Empty query`,
+		RSQL: ``,
+		SQL:  ``,
+	},
+	{
+		Name:           `Missing comparison operator`,
+		RSQL:           `name;age=gt=30`,
+		WantParseError: true,
+	},
+	{
+		Name:           `Unclosed FIQL operator`,
+		RSQL:           `age=gt30`,
+		WantParseError: true,
+	},
+	{
+		Name:           `Missing closing parenthesis`,
+		RSQL:           `(a==1,b==2`,
+		WantParseError: true,
+	},
+}
+
+func TestRSQL(t *testing.T) {
+	for _, test := range rsqlTests {
+		test.Run(t)
+	}
+}
@@ -1,8 +1,8 @@
 package rqlParser
 
 import (
+	"encoding/json"
 	"fmt"
-	"net/url"
 	"strconv"
 	"strings"
 )
@@ -10,8 +10,49 @@ import (
 type TranslatorOpFunc func(*RqlNode) (string, error)
 
 type SqlTranslator struct {
-	rootNode  *RqlRootNode
-	sqlOpsDic map[string]TranslatorOpFunc
+	rootNode       *RqlRootNode
+	sqlOpsDic      map[string]TranslatorOpFunc
+	defaultLimit   int
+	maxLimit       int
+	strictMaxLimit bool
+	denyInfinity   bool
+	defaultSorts   []Sort
+	opSpecs        *OpSpecs
+}
+
+// SetDefaultLimit sets the limit used when the query has no limit. 0 means no limit.
+func (st *SqlTranslator) SetDefaultLimit(limit int) {
+	st.defaultLimit = limit
+}
+
+// SetMaxLimit sets the maximum limit (0 means no maximum). Greater limits
+// are clamped to the maximum, or rejected by Sql when strict is true.
+func (st *SqlTranslator) SetMaxLimit(limit int, strict bool) {
+	st.maxLimit = limit
+	st.strictMaxLimit = strict
+}
+
+// SetInfinityAllowed sets whether limit(Infinity) is allowed. When it is
+// not, Infinity is handled as a limit greater than the maximum limit and
+// rejected if there is no maximum limit.
+func (st *SqlTranslator) SetInfinityAllowed(allowed bool) {
+	st.denyInfinity = !allowed
+}
+
+// SetDefaultSort sets the sort keys (eg: -created, +id) appended to the sort
+// of the query when they are not already part of it, so the pagination is
+// deterministic.
+func (st *SqlTranslator) SetDefaultSort(sorts ...string) {
+	st.defaultSorts = nil
+	for _, s := range sorts {
+		sort := Sort{by: s}
+		if strings.HasPrefix(s, "-") {
+			sort = Sort{by: s[1:], desc: true}
+		} else if strings.HasPrefix(s, "+") {
+			sort = Sort{by: s[1:]}
+		}
+		st.defaultSorts = append(st.defaultSorts, sort)
+	}
 }
 
 func (st *SqlTranslator) SetOpFunc(op string, f TranslatorOpFunc) {
@@ -20,12 +61,32 @@ func (st *SqlTranslator) SetOpFunc(op string, f TranslatorOpFunc) {
 
 func (st *SqlTranslator) DeleteOpFunc(op string) {
 	delete(st.sqlOpsDic, strings.ToUpper(op))
+	st.opSpecs.Delete(op)
+}
+
+// SetOpSpec declares the arguments accepted by an operator (eg: a custom
+// operator added with SetOpFunc). They are validated before the translation.
+func (st *SqlTranslator) SetOpSpec(spec OpSpec) {
+	if st.opSpecs == nil {
+		st.opSpecs = &OpSpecs{specs: map[string]OpSpec{}}
+	}
+	st.opSpecs.Set(spec)
+}
+
+// SetOpSpecs replaces the registry used to validate the operators arguments
+// (eg: to use the one of the Parser), nil disables the validation. The
+// registry is copied so DeleteOpFunc and SetOpSpec don't change the given one.
+func (st *SqlTranslator) SetOpSpecs(specs *OpSpecs) {
+	st.opSpecs = specs.Clone()
 }
 
 func (st *SqlTranslator) Where() (string, error) {
 	if st.rootNode == nil {
 		return "", nil
 	}
+	if err := st.opSpecs.Validate(st.rootNode.Node); err != nil {
+		return "", err
+	}
 	return st.where(st.rootNode.Node)
 }
 
@@ -40,17 +101,74 @@ func (st *SqlTranslator) where(n *RqlNode) (string, error) {
 	return f(n)
 }
 
+// Limit returns the LIMIT clause. An invalid limit (see LimitErr) is replaced
+// by the maximum limit, or by the default limit when there is no maximum.
 func (st *SqlTranslator) Limit() (sql string) {
-	if st.rootNode == nil {
-		return
+	limit, err := st.limit()
+	if err != nil {
+		limit = ""
+		if st.maxLimit > 0 {
+			limit = strconv.Itoa(st.maxLimit)
+		} else if st.defaultLimit > 0 {
+			limit = strconv.Itoa(st.defaultLimit)
+		}
 	}
-	limit := st.rootNode.Limit()
-	if limit != "" && strings.ToUpper(limit) != "INFINITY" {
+	if limit != "" {
 		sql = " LIMIT " + limit
 	}
 	return
 }
 
+// LimitErr returns the LIMIT clause, or an error when the limit exceeds the
+// strict maximum limit or when limit(Infinity) is not allowed
+func (st *SqlTranslator) LimitErr() (sql string, err error) {
+	limit, err := st.limit()
+	if err != nil {
+		return "", err
+	}
+	if limit != "" {
+		sql = " LIMIT " + limit
+	}
+	return
+}
+
+func (st *SqlTranslator) limit() (string, error) {
+	if st.rootNode == nil {
+		return "", nil
+	}
+
+	limit := st.rootNode.Limit()
+	if limit == "" {
+		if st.defaultLimit > 0 {
+			return strconv.Itoa(st.defaultLimit), nil
+		}
+		return "", nil
+	}
+
+	if st.rootNode.LimitInt() == InfiniteLimit {
+		if !st.denyInfinity && st.maxLimit <= 0 {
+			return "", nil
+		}
+		if st.maxLimit <= 0 {
+			return "", fmt.Errorf("Infinity limit is not allowed")
+		}
+		return st.clampLimit(limit)
+	}
+
+	if st.maxLimit > 0 && st.rootNode.LimitInt() > st.maxLimit {
+		return st.clampLimit(limit)
+	}
+
+	return limit, nil
+}
+
+func (st *SqlTranslator) clampLimit(limit string) (string, error) {
+	if st.strictMaxLimit {
+		return "", fmt.Errorf("Limit %s exceeds the maximum limit of %d", limit, st.maxLimit)
+	}
+	return strconv.Itoa(st.maxLimit), nil
+}
+
 func (st *SqlTranslator) Offset() (sql string) {
 	if st.rootNode != nil && st.rootNode.Offset() != "" {
 		sql = " OFFSET " + st.rootNode.Offset()
@@ -58,17 +176,21 @@ func (st *SqlTranslator) Offset() (sql string) {
 	return
 }
 
+// Sort returns the ORDER BY clause. When the query has a before() cursor the
+// sort directions are reversed so the rows preceding the cursor are returned
+// first : the caller must reverse the returned rows.
 func (st *SqlTranslator) Sort() (sql string) {
 	if st.rootNode == nil {
 		return
 	}
-	sorts := st.rootNode.Sort()
+	sorts := st.Sorts()
+	reverse := st.rootNode.Before() != ""
 	if len(sorts) > 0 {
 		sql = " ORDER BY "
 		sep := ""
 		for _, sort := range sorts {
 			sql = sql + sep + sort.by
-			if sort.desc {
+			if sort.desc != reverse {
 				sql = sql + " DESC"
 			}
 			sep = ", "
@@ -78,6 +200,114 @@ func (st *SqlTranslator) Sort() (sql string) {
 	return
 }
 
+// Sorts returns the sort of the query followed by the default sort keys
+// which are not part of it
+func (st *SqlTranslator) Sorts() (sorts []Sort) {
+	if st.rootNode == nil {
+		return nil
+	}
+	sorts = append(sorts, st.rootNode.Sort()...)
+	for _, ds := range st.defaultSorts {
+		found := false
+		for _, s := range sorts {
+			if s.by == ds.by {
+				found = true
+				break
+			}
+		}
+		if !found {
+			sorts = append(sorts, ds)
+		}
+	}
+	return
+}
+
+// EncodeCursor returns the cursor for the sort keys returned by Sorts
+func (st *SqlTranslator) EncodeCursor(values ...interface{}) (string, error) {
+	return EncodeCursor(st.Sorts(), values...)
+}
+
+// Cursor returns the keyset pagination condition of the after() or before()
+// cursor. Sorts in a single direction produce a row value comparison
+// (eg: (price, id) > (10, 42)), mixed directions are expanded.
+func (st *SqlTranslator) Cursor() (sql string, err error) {
+	if st.rootNode == nil {
+		return
+	}
+
+	cursor, before := st.rootNode.After(), false
+	if cursor == "" {
+		cursor, before = st.rootNode.Before(), true
+	}
+	if cursor == "" {
+		return
+	}
+
+	sorts := st.Sorts()
+	values, err := DecodeCursor(cursor, sorts)
+	if err != nil {
+		return
+	}
+
+	fields := make([]string, len(sorts))
+	sqlValues := make([]string, len(sorts))
+	ops := make([]string, len(sorts))
+	sameDirection := true
+
+	for i, sort := range sorts {
+		if !IsValidField(sort.by) {
+			return "", fmt.Errorf("Invalid field name : %s", sort.by)
+		}
+		fields[i] = sort.by
+
+		if sqlValues[i], err = sqlCursorValue(values[i]); err != nil {
+			return "", err
+		}
+
+		ops[i] = ">"
+		if sort.desc != before {
+			ops[i] = "<"
+		}
+		sameDirection = sameDirection && ops[i] == ops[0]
+	}
+
+	if len(sorts) == 1 {
+		return fmt.Sprintf("(%s %s %s)", fields[0], ops[0], sqlValues[0]), nil
+	}
+
+	if sameDirection {
+		return fmt.Sprintf("((%s) %s (%s))", strings.Join(fields, ", "), ops[0], strings.Join(sqlValues, ", ")), nil
+	}
+
+	// (a > x) OR (a = x AND b < y) OR (a = x AND b = y AND c > z)...
+	var conditions []string
+	for i := range sorts {
+		var terms []string
+		for j := 0; j < i; j++ {
+			terms = append(terms, fmt.Sprintf("%s = %s", fields[j], sqlValues[j]))
+		}
+		terms = append(terms, fmt.Sprintf("%s %s %s", fields[i], ops[i], sqlValues[i]))
+		conditions = append(conditions, "("+strings.Join(terms, " AND ")+")")
+	}
+
+	return "(" + strings.Join(conditions, " OR ") + ")", nil
+}
+
+func sqlCursorValue(v interface{}) (string, error) {
+	switch value := v.(type) {
+	case json.Number:
+		if _, err := strconv.ParseInt(string(value), 10, 64); err == nil {
+			return string(value), nil
+		}
+		return Quote(string(value)), nil
+	case string:
+		return Quote(value), nil
+	case bool:
+		return strings.ToUpper(strconv.FormatBool(value)), nil
+	}
+	return "", fmt.Errorf("Unsupported cursor value : %v", v)
+}
+
 func (st *SqlTranslator) Sql() (sql string, err error) {
 	var where string
 
@@ -86,8 +316,17 @@ func (st *SqlTranslator) Sql() (sql string, err error) {
 		return
 	}
 
-	if len(where) > 0 {
+	cursor, err := st.Cursor()
+	if err != nil {
+		return
+	}
+
+	if len(where) > 0 && len(cursor) > 0 {
+		sql = `WHERE ` + where + ` AND ` + cursor
+	} else if len(where) > 0 {
 		sql = `WHERE ` + where
+	} else if len(cursor) > 0 {
+		sql = `WHERE ` + cursor
 	}
 
 	sort := st.Sort()
@@ -95,10 +334,11 @@ func (st *SqlTranslator) Sql() (sql string, err error) {
 		sql += sort
 	}
 
-	limit := st.Limit()
-	if len(limit) > 0 {
-		sql += limit
+	limit, err := st.LimitErr()
+	if err != nil {
+		return "", err
 	}
+	sql += limit
 
 	offset := st.Offset()
 	if len(offset) > 0 {
@@ -109,7 +349,7 @@ func (st *SqlTranslator) Sql() (sql string, err error) {
 }
 
 func NewSqlTranslator(r *RqlRootNode) (st *SqlTranslator) {
-	st = &SqlTranslator{r, map[string]TranslatorOpFunc{}}
+	st = &SqlTranslator{rootNode: r, sqlOpsDic: map[string]TranslatorOpFunc{}, opSpecs: NewOpSpecs()}
 
 	starToPercentFunc := AlterStringFunc(func(s string) (string, error) {
 		// v, err := url.QueryUnescape(s)
@@ -132,6 +372,8 @@ func NewSqlTranslator(r *RqlRootNode) (st *SqlTranslator) {
 	st.SetOpFunc("GE", st.GetFieldValueTranslatorFunc(">=", nil))
 	st.SetOpFunc("LE", st.GetFieldValueTranslatorFunc("<=", nil))
 	st.SetOpFunc("NOT", st.GetOpFirstTranslatorFunc("NOT", nil))
+	st.SetOpFunc("IN", st.GetInTranslatorFunc("IN"))
+	st.SetOpFunc("OUT", st.GetInTranslatorFunc("NOT IN"))
 	// st.SetOpFunc("SUM", st.GetOpFirstTranslatorFunc("SUM", nil))
 
 	return
@@ -139,15 +381,17 @@ func NewSqlTranslator(r *RqlRootNode) (st *SqlTranslator) {
 
 func (st *SqlTranslator) GetEqualityTranslatorOpFunc(op, specialOp string) TranslatorOpFunc {
 	return TranslatorOpFunc(func(n *RqlNode) (s string, err error) {
-		value, err := url.QueryUnescape(n.Args[1].(string))
-		if err != nil {
-			return "", err
+		if len(n.Args) != 2 {
+			return "", &ArgumentError{Op: n.Op, Reason: "requires 2 arguments"}
 		}
 
+		// The value has already been unescaped by the scanner
+		value, _ := n.Args[1].(string)
+
 		if value == `null` || value == `true` || value == `false` {
-			field := n.Args[0].(string)
-			if !IsValidField(field) {
-				return ``, fmt.Errorf("Invalid field name : %s", field)
+			field, ok := n.Args[0].(string)
+			if !ok || !IsValidField(field) {
+				return ``, fmt.Errorf("Invalid field name : %v", n.Args[0])
 			}
 
 			return fmt.Sprintf("(%s %s %s)", field, specialOp, strings.ToUpper(value)), nil
@@ -161,6 +405,10 @@ func (st *SqlTranslator) GetAndOrTranslatorOpFunc(op string) TranslatorOpFunc {
 	return TranslatorOpFunc(func(n *RqlNode) (s string, err error) {
 		sep := ""
 
+		if len(n.Args) == 0 {
+			return "", &ArgumentError{Op: n.Op, Reason: "requires at least 1 argument"}
+		}
+
 		for _, a := range n.Args {
 			s = s + sep
 			switch v := a.(type) {
@@ -176,6 +424,8 @@ func (st *SqlTranslator) GetAndOrTranslatorOpFunc(op string) TranslatorOpFunc {
 					return "", err
 				}
 				s = s + _s
+			default:
+				return "", &ArgumentError{Op: n.Op, Arg: a, Reason: "unsupported argument type"}
 			}
 
 			sep = " " + op + " "
@@ -191,6 +441,10 @@ func (st *SqlTranslator) GetFieldValueTranslatorFunc(op string, valueAlterFunc A
 	return TranslatorOpFunc(func(n *RqlNode) (s string, err error) {
 		sep := ""
 
+		if len(n.Args) < 2 {
+			return "", &ArgumentError{Op: n.Op, Reason: "requires at least 2 arguments"}
+		}
+
 		for i, a := range n.Args {
 			s += sep
 			switch v := a.(type) {
@@ -216,6 +470,15 @@ func (st *SqlTranslator) GetFieldValueTranslatorFunc(op string, valueAlterFunc A
 					}
 				}
 
+				s += _s
+			case StringLiteral:
+				if i == 0 {
+					return "", fmt.Errorf("First argument must be a valid field name (arg: %s)", v)
+				}
+				_s, err := quoteStringLiteral(v, valueAlterFunc)
+				if err != nil {
+					return "", err
+				}
 				s += _s
 			case *RqlNode:
 				var _s string
@@ -224,6 +487,8 @@ func (st *SqlTranslator) GetFieldValueTranslatorFunc(op string, valueAlterFunc A
 					return "", err
 				}
 				s = s + _s
+			default:
+				return "", &ArgumentError{Op: n.Op, Arg: a, Reason: "unsupported argument type"}
 			}
 
 			sep = " " + op + " "
@@ -233,6 +498,39 @@ func (st *SqlTranslator) GetFieldValueTranslatorFunc(op string, valueAlterFunc A
 	})
 }
 
+// GetInTranslatorFunc returns a TranslatorOpFunc for the operators comparing
+// the field (first argument) to a list of values (eg: in(price,10,20))
+func (st *SqlTranslator) GetInTranslatorFunc(op string) TranslatorOpFunc {
+	return TranslatorOpFunc(func(n *RqlNode) (s string, err error) {
+		if len(n.Args) < 2 {
+			return "", &ArgumentError{Op: n.Op, Reason: "requires at least 2 arguments"}
+		}
+
+		field, ok := n.Args[0].(string)
+		if !ok || !IsValidField(field) {
+			return "", fmt.Errorf("First argument must be a valid field name (arg: %v)", n.Args[0])
+		}
+
+		values := make([]string, len(n.Args)-1)
+		for i, a := range n.Args[1:] {
+			switch v := a.(type) {
+			case string:
+				if _, err := strconv.ParseInt(v, 10, 64); err == nil {
+					values[i] = v
+				} else {
+					values[i] = Quote(v)
+				}
+			case StringLiteral:
+				values[i] = Quote(string(v))
+			default:
+				return "", &ArgumentError{Op: n.Op, Arg: a, Reason: "must be a value"}
+			}
+		}
+
+		return fmt.Sprintf("(%s %s (%s))", field, op, strings.Join(values, ", ")), nil
+	})
+}
+
 func (st *SqlTranslator) GetOpFirstTranslatorFunc(op string, valueAlterFunc AlterStringFunc) TranslatorOpFunc {
 	return TranslatorOpFunc(func(n *RqlNode) (s string, err error) {
 		sep := ""
@@ -254,6 +552,12 @@ func (st *SqlTranslator) GetOpFirstTranslatorFunc(op string, valueAlterFunc Alte
 					_s = Quote(v)
 				}
 
+				s += _s
+			case StringLiteral:
+				_s, err := quoteStringLiteral(v, valueAlterFunc)
+				if err != nil {
+					return "", err
+				}
 				s += _s
 			case *RqlNode:
 				var _s string
@@ -262,6 +566,8 @@ func (st *SqlTranslator) GetOpFirstTranslatorFunc(op string, valueAlterFunc Alte
 					return "", err
 				}
 				s = s + _s
+			default:
+				return "", &ArgumentError{Op: n.Op, Arg: a, Reason: "unsupported argument type"}
 			}
 
 			sep = ", "
@@ -271,9 +577,24 @@ func (st *SqlTranslator) GetOpFirstTranslatorFunc(op string, valueAlterFunc Alte
 	})
 }
 
+// quoteStringLiteral returns the SQL string of a quoted value, which is never
+// handled as a number
+func quoteStringLiteral(s StringLiteral, valueAlterFunc AlterStringFunc) (string, error) {
+	if valueAlterFunc != nil {
+		return valueAlterFunc(string(s))
+	}
+	return Quote(string(s)), nil
+}
+
 func IsValidField(s string) bool {
+	if s == "" {
+		return false
+	}
 	for _, ch := range s {
-		if !isLetter(ch) && !isDigit(ch) && ch != '_' && ch != '-' && ch != '.' {
+		if ch == '_' || ch == '-' || ch == '.' || (ch >= '0' && ch <= '9') {
+			continue
+		}
+		if !isLetter(ch) && !isDigit(ch) && !isMark(ch) {
 			return false
 		}
 	}
@@ -0,0 +1,126 @@
+package rqlParser
+
+import (
+	"strings"
+	"testing"
+)
+
+type TranslatorOptionsTest struct {
+	Name                string   // Name of the test
+	RQL                 string   // Input RQL query
+	DefaultLimit        int      // Default limit of the translator
+	MaxLimit            int      // Maximum limit of the translator
+	StrictMaxLimit      bool     // Reject limits greater than the maximum limit
+	DenyInfinity        bool     // Reject limit(Infinity)
+	DefaultSort         []string // Default sort of the translator
+	SQL                 string   // Expected Output SQL
+	WantTranslatorError bool     // Test should raise an error when translating to SQL
+}
+
+func (test *TranslatorOptionsTest) Run(t *testing.T) {
+	rqlNode, err := NewParser().Parse(strings.NewReader(test.RQL))
+	if err != nil {
+		t.Fatalf("(%s) Unexpected parse error : %v", test.Name, err)
+	}
+
+	st := NewSqlTranslator(rqlNode)
+	st.SetDefaultLimit(test.DefaultLimit)
+	st.SetMaxLimit(test.MaxLimit, test.StrictMaxLimit)
+	st.SetInfinityAllowed(!test.DenyInfinity)
+	st.SetDefaultSort(test.DefaultSort...)
+
+	s, err := st.Sql()
+	if test.WantTranslatorError != (err != nil) {
+		t.Fatalf("(%s) Expecting error :%v\nGot error : %v \n\tSQL = %s", test.Name, test.WantTranslatorError, err, s)
+	}
+
+	if s != test.SQL {
+		t.Fatalf("(%s) Translated SQL doesn’t match the expected one %s vs %s", test.Name, s, test.SQL)
+	}
+}
+
+var translatorOptionsTests = []TranslatorOptionsTest{
+	{
+		Name:         `Default limit`,
+		RQL:          `eq(foo,42)`,
+		DefaultLimit: 20,
+		SQL:          `WHERE (foo = 42) LIMIT 20`,
+	},
+	{
+		Name:         `Query limit overrides default limit`,
+		RQL:          `eq(foo,42)&limit(5)`,
+		DefaultLimit: 20,
+		MaxLimit:     100,
+		SQL:          `WHERE (foo = 42) LIMIT 5`,
+	},
+	{
+		Name:     `Limit clamped to max limit`,
+		RQL:      `limit(1000,10)`,
+		MaxLimit: 100,
+		SQL:      ` LIMIT 100 OFFSET 10`,
+	},
+	{
+		Name:                `Limit greater than strict max limit`,
+		RQL:                 `limit(1000)`,
+		MaxLimit:            100,
+		StrictMaxLimit:      true,
+		WantTranslatorError: true,
+	},
+	{
+		Name:     `Infinity clamped to max limit`,
+		RQL:      `limit(Infinity)`,
+		MaxLimit: 100,
+		SQL:      ` LIMIT 100`,
+	},
+	{
+		Name: `Infinity allowed`,
+		RQL:  `limit(Infinity)`,
+		SQL:  ``,
+	},
+	{
+		Name:                `Infinity denied`,
+		RQL:                 `limit(Infinity)`,
+		DenyInfinity:        true,
+		WantTranslatorError: true,
+	},
+	{
+		Name:        `Default sort`,
+		RQL:         `eq(foo,42)`,
+		DefaultSort: []string{`-created`, `id`},
+		SQL:         `WHERE (foo = 42) ORDER BY created DESC, id`,
+	},
+	{
+		Name:        `Default sort as tie-breaker`,
+		RQL:         `sort(+id,-price)`,
+		DefaultSort: []string{`-created`, `+id`},
+		SQL:         ` ORDER BY id, price DESC, created DESC`,
+	},
+}
+
+func TestTranslatorOptions(t *testing.T) {
+	for _, test := range translatorOptionsTests {
+		test.Run(t)
+	}
+}
+
+func TestLimitErr(t *testing.T) {
+	rqlNode, err := NewParser().Parse(strings.NewReader(`limit(Infinity)`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := NewSqlTranslator(rqlNode)
+	st.SetInfinityAllowed(false)
+
+	if _, err = st.LimitErr(); err == nil {
+		t.Fatalf("Expecting an error for a denied limit(Infinity) without maximum limit")
+	}
+
+	st.SetDefaultLimit(20)
+	if s := st.Limit(); s != ` LIMIT 20` {
+		t.Fatalf("Denied limit(Infinity) must fall back to the default limit, got %s", s)
+	}
+	st.SetMaxLimit(100, false)
+	if s, err := st.LimitErr(); err != nil || s != ` LIMIT 100` {
+		t.Fatalf("limit(Infinity) must be clamped to the maximum limit, got %s (%v)", s, err)
+	}
+}
@@ -0,0 +1,48 @@
+package rqlParser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// nativeValue returns the Go value of a value argument : the unquoted numbers
+// are int64 or float64, the unquoted booleans bool, the unquoted null nil and
+// the other values string
+func nativeValue(arg interface{}) interface{} {
+	switch v := arg.(type) {
+	case StringLiteral:
+		return string(v)
+	case string:
+		switch v {
+		case "null":
+			return nil
+		case "true":
+			return true
+		case "false":
+			return false
+		}
+		if isNumber(v) {
+			if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return i
+			}
+			f, _ := strconv.ParseFloat(v, 64)
+			return f
+		}
+	}
+	return arg
+}
+
+// isNumber reports whether the value is a decimal number (eg: -1.5e3 but not
+// NaN or Inf). The values with leading zeros (eg: zip codes like 01234) are
+// not numbers as the zeros would be lost.
+func isNumber(s string) bool {
+	if _, err := strconv.ParseFloat(s, 64); err != nil {
+		return false
+	}
+	if digits := strings.TrimLeft(s, "+-"); len(digits) > 1 && digits[0] == '0' && isDigit(rune(digits[1])) {
+		return false
+	}
+	return strings.IndexFunc(s, func(ch rune) bool {
+		return !isDigit(ch) && !strings.ContainsRune("+-.eE", ch)
+	}) < 0
+}
@@ -0,0 +1,37 @@
+package rqlParser
+
+import (
+	"reflect"
+	"testing"
+)
+
+type NativeValueTest struct {
+	Arg      interface{} // Value argument
+	Expected interface{} // Expected Go value
+}
+
+func (test *NativeValueTest) Run(t *testing.T) {
+	if v := nativeValue(test.Arg); !reflect.DeepEqual(v, test.Expected) {
+		t.Fatalf("(%#v) Native value doesn’t match the expected one %#v vs %#v", test.Arg, v, test.Expected)
+	}
+}
+
+var nativeValueTests = []NativeValueTest{
+	{Arg: `42`, Expected: int64(42)},
+	{Arg: `-0`, Expected: int64(0)},
+	{Arg: `0.5`, Expected: 0.5},
+	{Arg: `-1.5e3`, Expected: -1500.0},
+	{Arg: `true`, Expected: true},
+	{Arg: `null`, Expected: nil},
+	{Arg: `01234`, Expected: `01234`},
+	{Arg: `-007`, Expected: `-007`},
+	{Arg: `00.5`, Expected: `00.5`},
+	{Arg: `NaN`, Expected: `NaN`},
+	{Arg: StringLiteral(`42`), Expected: `42`},
+}
+
+func TestNativeValue(t *testing.T) {
+	for _, test := range nativeValueTests {
+		test.Run(t)
+	}
+}
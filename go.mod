@@ -1 +1,3 @@
 module github.com/tbaud0n/go-rql-parser
+
+go 1.18